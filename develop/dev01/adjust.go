@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// errOffsetTooLarge возвращается, если смещение превышает порог паники
+var errOffsetTooLarge = errors.New("смещение превышает допустимый порог")
+
+// errSlewTooLarge возвращается при попытке плавно подстроить часы на
+// смещение больше maxSlew
+var errSlewTooLarge = errors.New("смещение слишком велико для плавной подстройки")
+
+// maxSlew — максимальное смещение, которое принимает однократная плавная
+// подстройка часов (adjtime)
+const maxSlew = 500 * time.Millisecond
+
+// Действия по коррекции локальных часов
+const (
+	actionNone = "none"
+	actionSlew = "slew"
+	actionStep = "step"
+)
+
+// SystemClock управляет системными часами; в тестах подменяется фейком
+type SystemClock interface {
+	// Step мгновенно переводит часы на offset
+	Step(offset time.Duration) error
+	// Slew плавно подстраивает часы на offset, изменяя их скорость
+	Slew(offset time.Duration) error
+}
+
+// Adjustment описывает необходимую коррекцию локальных часов
+type Adjustment struct {
+	Action string
+	Offset time.Duration
+}
+
+// String возвращает описание коррекции
+func (a Adjustment) String() string {
+	return fmt.Sprintf("%s %+.9f с", a.Action, a.Offset.Seconds())
+}
+
+// planAdjustment выбирает способ коррекции по величине смещения: смещения
+// меньше minOffset игнорируются, до stepThreshold часы подстраиваются
+// плавно, большие смещения исправляются скачком. Плавная подстройка
+// ограничена maxSlew, поэтому смещения больше него исправляются скачком
+// при любом stepThreshold. Смещения больше panicThreshold отклоняются,
+// если panicThreshold не равен нулю.
+func planAdjustment(offset, minOffset, stepThreshold, panicThreshold time.Duration) (Adjustment, error) {
+	a := Adjustment{Action: actionNone, Offset: offset}
+	abs := offset.Abs()
+
+	switch {
+	case panicThreshold > 0 && abs > panicThreshold:
+		return a, fmt.Errorf("%w: %v > %v", errOffsetTooLarge, abs, panicThreshold)
+	case abs < minOffset || abs == 0:
+		return a, nil
+	case abs < stepThreshold && abs <= maxSlew:
+		a.Action = actionSlew
+	default:
+		a.Action = actionStep
+	}
+	return a, nil
+}
+
+// applyAdjustment применяет коррекцию к системным часам
+func applyAdjustment(clock SystemClock, a Adjustment) error {
+	switch a.Action {
+	case actionSlew:
+		return clock.Slew(a.Offset)
+	case actionStep:
+		return clock.Step(a.Offset)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// FakeClock запоминает примененные коррекции вместо изменения системных часов
+type FakeClock struct {
+	steps, slews []time.Duration
+}
+
+// Step запоминает перевод часов скачком
+func (c *FakeClock) Step(offset time.Duration) error {
+	c.steps = append(c.steps, offset)
+	return nil
+}
+
+// Slew запоминает плавную подстройку часов
+func (c *FakeClock) Slew(offset time.Duration) error {
+	c.slews = append(c.slews, offset)
+	return nil
+}
+
+func TestPlanAdjustment(t *testing.T) {
+	tests := []struct {
+		name   string
+		offset time.Duration
+		action string
+		err    error
+	}{
+		{"Zero", 0, actionNone, nil},
+		{"Below minimum", 500 * time.Microsecond, actionNone, nil},
+		{"Slew forward", 50 * time.Millisecond, actionSlew, nil},
+		{"Slew backward", -100 * time.Millisecond, actionSlew, nil},
+		{"Step at threshold", 128 * time.Millisecond, actionStep, nil},
+		{"Step backward", -3 * time.Second, actionStep, nil},
+		{"Panic", time.Hour, actionNone, errOffsetTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := planAdjustment(tt.offset, time.Millisecond, 128*time.Millisecond, 1000*time.Second)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("planAdjustment вернула ошибку %v; want %v", err, tt.err)
+			}
+			if a.Action != tt.action || a.Offset != tt.offset {
+				t.Errorf("planAdjustment() = %v; want %s %v", a, tt.action, tt.offset)
+			}
+		})
+	}
+}
+
+func TestPlanAdjustmentSlewLimit(t *testing.T) {
+	// Даже при большом пороге -step плавная подстройка не превышает maxSlew
+	for offset, action := range map[time.Duration]string{
+		maxSlew:                     actionSlew,
+		-maxSlew:                    actionSlew,
+		maxSlew + time.Microsecond:  actionStep,
+		-maxSlew - time.Microsecond: actionStep,
+		30 * time.Second:            actionStep,
+	} {
+		a, err := planAdjustment(offset, time.Millisecond, time.Minute, 0)
+		if err != nil {
+			t.Fatalf("planAdjustment(%v) вернула ошибку: %v", offset, err)
+		}
+		if a.Action != action {
+			t.Errorf("planAdjustment(%v) = %v; want %s", offset, a, action)
+		}
+	}
+}
+
+func TestApplyAdjustment(t *testing.T) {
+	clock := &FakeClock{}
+
+	for _, a := range []Adjustment{
+		{actionSlew, 20 * time.Millisecond},
+		{actionStep, -2 * time.Second},
+		{actionNone, 0},
+	} {
+		if err := applyAdjustment(clock, a); err != nil {
+			t.Fatalf("applyAdjustment вернула ошибку: %v", err)
+		}
+	}
+
+	if len(clock.slews) != 1 || clock.slews[0] != 20*time.Millisecond {
+		t.Errorf("Неверные плавные коррекции: %v", clock.slews)
+	}
+	if len(clock.steps) != 1 || clock.steps[0] != -2*time.Second {
+		t.Errorf("Неверные коррекции скачком: %v", clock.steps)
+	}
+}
+
+func TestAdjustmentString(t *testing.T) {
+	a := Adjustment{Action: actionSlew, Offset: -1500 * time.Microsecond}
+	if s := a.String(); s != "slew -0.001500000 с" {
+		t.Errorf("String() = %q", s)
+	}
+}
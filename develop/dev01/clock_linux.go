@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// unixClock управляет системными часами через settimeofday и adjtimex
+type unixClock struct{}
+
+// Step переводит часы вызовом settimeofday
+func (unixClock) Step(offset time.Duration) error {
+	tv := unix.NsecToTimeval(time.Now().Add(offset).UnixNano())
+	return unix.Settimeofday(&tv)
+}
+
+// Slew подстраивает часы вызовом adjtimex в режиме однократной коррекции (как adjtime)
+func (unixClock) Slew(offset time.Duration) error {
+	if offset.Abs() > maxSlew {
+		return fmt.Errorf("%w: %v > %v", errSlewTooLarge, offset.Abs(), maxSlew)
+	}
+	tx := unix.Timex{Modes: unix.ADJ_OFFSET_SINGLESHOT}
+	setTimexField(&tx.Offset, offset.Microseconds())
+	_, err := unix.Adjtimex(&tx)
+	return err
+}
+
+// setTimexField записывает значение в поле Timex, разрядность которого
+// зависит от архитектуры: int64 на 64-битных, int32 на 32-битных
+func setTimexField[T int32 | int64](field *T, v int64) {
+	*field = T(v)
+}
+
+// newSystemClock возвращает системные часы текущей платформы
+func newSystemClock() SystemClock {
+	return unixClock{}
+}
@@ -0,0 +1,29 @@
+//go:build !linux
+
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+// errClockUnsupported возвращается на платформах без поддержки коррекции часов
+var errClockUnsupported = errors.New("коррекция часов не поддерживается на этой платформе")
+
+// unsupportedClock сообщает об отсутствии поддержки коррекции часов
+type unsupportedClock struct{}
+
+// Step не поддерживается
+func (unsupportedClock) Step(offset time.Duration) error {
+	return errClockUnsupported
+}
+
+// Slew не поддерживается
+func (unsupportedClock) Slew(offset time.Duration) error {
+	return errClockUnsupported
+}
+
+// newSystemClock возвращает системные часы текущей платформы
+func newSystemClock() SystemClock {
+	return unsupportedClock{}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/beevik/ntp"
+)
+
+// ErrorKind — класс ошибки получения времени с NTP сервера
+type ErrorKind string
+
+// Классы ошибок получения времени
+const (
+	KindDNS             ErrorKind = "dns"
+	KindTimeout         ErrorKind = "timeout"
+	KindNetwork         ErrorKind = "network"
+	KindKissRate        ErrorKind = "kod_rate"
+	KindKissDeny        ErrorKind = "kod_deny"
+	KindUnsynchronized  ErrorKind = "unsynchronized"
+	KindInvalidResponse ErrorKind = "invalid_response"
+)
+
+// kindExitCodes сопоставляет классам ошибок коды выхода
+var kindExitCodes = map[ErrorKind]int{
+	KindDNS:             exitDNS,
+	KindTimeout:         exitTimeout,
+	KindNetwork:         exitNTPError,
+	KindKissRate:        exitKissRate,
+	KindKissDeny:        exitKissDeny,
+	KindUnsynchronized:  exitUnsynchronized,
+	KindInvalidResponse: exitInvalidResponse,
+}
+
+// NTPError описывает классифицированную ошибку получения времени
+type NTPError struct {
+	Kind ErrorKind
+	Host string
+	// KissCode содержит код Kiss-o'-Death, если сервер его прислал
+	KissCode string
+	Err      error
+}
+
+// Error возвращает описание ошибки
+func (e *NTPError) Error() string {
+	if e.KissCode != "" {
+		return fmt.Sprintf("%s: %s: сервер прислал Kiss-o'-Death %s: %v", e.Kind, e.Host, e.KissCode, e.Err)
+	}
+	return fmt.Sprintf("%s: %s: %v", e.Kind, e.Host, e.Err)
+}
+
+// Unwrap возвращает исходную ошибку
+func (e *NTPError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode возвращает код выхода, соответствующий классу ошибки
+func (e *NTPError) ExitCode() int {
+	if code, ok := kindExitCodes[e.Kind]; ok {
+		return code
+	}
+	return exitNTPError
+}
+
+// Message возвращает машиночитаемое описание ошибки в формате logfmt
+func (e *NTPError) Message() string {
+	msg := fmt.Sprintf("error=%s host=%q", e.Kind, e.Host)
+	if e.KissCode != "" {
+		msg += fmt.Sprintf(" kiss_code=%s", e.KissCode)
+	}
+	return msg + fmt.Sprintf(" detail=%q", e.Err.Error())
+}
+
+// classifyError классифицирует ошибку запроса или некорректный ответ сервера.
+// Возвращает nil, если ответ пригоден для синхронизации.
+func classifyError(host string, resp *ntp.Response, err error) error {
+	if err != nil {
+		var dnsErr *net.DNSError
+		var netErr net.Error
+		switch {
+		case errors.As(err, &dnsErr):
+			return &NTPError{Kind: KindDNS, Host: host, Err: err}
+		case errors.As(err, &netErr) && netErr.Timeout():
+			return &NTPError{Kind: KindTimeout, Host: host, Err: err}
+		}
+		return &NTPError{Kind: KindNetwork, Host: host, Err: err}
+	}
+
+	if resp.IsKissOfDeath() {
+		switch resp.KissCode {
+		case "RATE":
+			return &NTPError{Kind: KindKissRate, Host: host, KissCode: resp.KissCode, Err: ntp.ErrKissOfDeath}
+		case "DENY", "RSTR":
+			return &NTPError{Kind: KindKissDeny, Host: host, KissCode: resp.KissCode, Err: ntp.ErrKissOfDeath}
+		}
+		return &NTPError{Kind: KindUnsynchronized, Host: host, KissCode: resp.KissCode, Err: ntp.ErrInvalidStratum}
+	}
+
+	if err := resp.Validate(); err != nil {
+		if resp.Leap == ntp.LeapNotInSync || errors.Is(err, ntp.ErrInvalidStratum) {
+			return &NTPError{Kind: KindUnsynchronized, Host: host, Err: err}
+		}
+		return &NTPError{Kind: KindInvalidResponse, Host: host, Err: err}
+	}
+
+	return nil
+}
+
+// queryHost запрашивает сервер и проверяет, что ответ пригоден для синхронизации
+func queryHost(client NTPClient, host string) (*ntp.Response, error) {
+	resp, err := client.Query(host)
+	if err := classifyError(host, resp, err); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// selectionExitCode возвращает код выхода для ошибки выбора времени по
+// опросу samples. Если не ответил ни один сервер, код определяется ошибкой
+// первого из них: при общем для всех классе ошибок он совпадает с кодом
+// этого класса, например exitKissDeny, если все серверы прислали DENY.
+func selectionExitCode(samples []serverSample, err error) int {
+	if errors.Is(err, errNoResponses) {
+		for _, s := range samples {
+			if s.Err != nil {
+				return exitCode(s.Err)
+			}
+		}
+	}
+	return exitNTPError
+}
+
+// exitCode возвращает код выхода для ошибки получения времени
+func exitCode(err error) int {
+	var ntpErr *NTPError
+	if errors.As(err, &ntpErr) {
+		return ntpErr.ExitCode()
+	}
+	return exitNTPError
+}
+
+// errorMessage возвращает машиночитаемое описание ошибки получения времени
+func errorMessage(err error) string {
+	var ntpErr *NTPError
+	if errors.As(err, &ntpErr) {
+		return ntpErr.Message()
+	}
+	return fmt.Sprintf("error=%s detail=%q", KindNetwork, err.Error())
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beevik/ntp"
+)
+
+func TestClassifyError(t *testing.T) {
+	ref := time.Date(2023, time.March, 12, 15, 30, 0, 0, time.UTC)
+	valid := ntp.Response{Time: ref, ReferenceTime: ref, Stratum: 2}
+
+	tests := []struct {
+		name     string
+		resp     ntp.Response
+		err      error
+		kind     ErrorKind
+		exitCode int
+	}{
+		{"DNS", ntp.Response{}, &net.DNSError{Err: "no such host", Name: "bad.host", IsNotFound: true}, KindDNS, exitDNS},
+		{"Timeout", ntp.Response{}, &net.OpError{Op: "read", Net: "udp", Err: os.ErrDeadlineExceeded}, KindTimeout, exitTimeout},
+		{"Network", ntp.Response{}, errors.New("connection refused"), KindNetwork, exitNTPError},
+		{"KoD RATE", ntp.Response{Stratum: 0, KissCode: "RATE"}, nil, KindKissRate, exitKissRate},
+		{"KoD DENY", ntp.Response{Stratum: 0, KissCode: "DENY"}, nil, KindKissDeny, exitKissDeny},
+		{"KoD RSTR", ntp.Response{Stratum: 0, KissCode: "RSTR"}, nil, KindKissDeny, exitKissDeny},
+		{"Stratum 0", ntp.Response{Stratum: 0, KissCode: "INIT"}, nil, KindUnsynchronized, exitUnsynchronized},
+		{"Leap 3", ntp.Response{Time: ref, ReferenceTime: ref, Stratum: 2, Leap: ntp.LeapNotInSync}, nil, KindUnsynchronized, exitUnsynchronized},
+		{"Stratum 16", ntp.Response{Time: ref, ReferenceTime: ref, Stratum: 16}, nil, KindUnsynchronized, exitUnsynchronized},
+		{"Invalid time", ntp.Response{Time: ref, ReferenceTime: ref.Add(time.Hour), Stratum: 2}, nil, KindInvalidResponse, exitInvalidResponse},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := tt.resp
+			err := classifyError("a", &resp, tt.err)
+
+			var ntpErr *NTPError
+			if !errors.As(err, &ntpErr) {
+				t.Fatalf("Ожидалась ошибка NTPError, получено %v", err)
+			}
+			if ntpErr.Kind != tt.kind {
+				t.Errorf("Класс ошибки %s; want %s", ntpErr.Kind, tt.kind)
+			}
+			if code := exitCode(err); code != tt.exitCode {
+				t.Errorf("Код выхода %d; want %d", code, tt.exitCode)
+			}
+			if msg := errorMessage(err); !strings.HasPrefix(msg, "error="+string(tt.kind)+` host="a"`) {
+				t.Errorf("Неверное машиночитаемое описание: %s", msg)
+			}
+		})
+	}
+
+	if err := classifyError("a", &valid, nil); err != nil {
+		t.Errorf("Корректный ответ классифицирован как ошибка: %v", err)
+	}
+}
+
+func TestNTPErrorMessage(t *testing.T) {
+	err := &NTPError{Kind: KindKissRate, Host: "a", KissCode: "RATE", Err: ntp.ErrKissOfDeath}
+
+	expected := `error=kod_rate host="a" kiss_code=RATE detail="kiss of death received"`
+	if msg := err.Message(); msg != expected {
+		t.Errorf("Message() = %s; want %s", msg, expected)
+	}
+	if !errors.Is(err, ntp.ErrKissOfDeath) {
+		t.Error("Ожидалось, что NTPError раскрывает исходную ошибку")
+	}
+}
+
+func TestSelectionExitCode(t *testing.T) {
+	deny := ScriptedReply{KissCode: "DENY"}
+	rate := ScriptedReply{KissCode: "RATE"}
+	client := &ScriptedNTPClient{replies: map[string][]ScriptedReply{
+		"deny1": {deny}, "deny2": {deny}, "rate": {rate}, "ok": {{}},
+	}}
+
+	tests := []struct {
+		name  string
+		hosts []string
+		code  int
+	}{
+		{"Все прислали DENY", []string{"deny1", "deny2"}, exitKissDeny},
+		{"Разные классы", []string{"rate", "deny1"}, exitKissRate},
+		{"Сервер недоступен", []string{"missing"}, exitNTPError},
+	}
+
+	for _, tt := range tests {
+		samples := queryServers(client, tt.hosts)
+		_, err := selectOffset(samples, 100*time.Millisecond)
+		if err == nil {
+			t.Fatalf("%s: ожидалась ошибка выбора времени", tt.name)
+		}
+		if code := selectionExitCode(samples, err); code != tt.code {
+			t.Errorf("%s: код выхода %d; want %d", tt.name, code, tt.code)
+		}
+	}
+
+	// Рассогласование ответивших серверов не зависит от ошибок остальных
+	samples := queryServers(client, []string{"ok", "deny1"})
+	if code := selectionExitCode(samples, errNoMajority); code != exitNTPError {
+		t.Errorf("Нет большинства: код выхода %d; want %d", code, exitNTPError)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Именованные форматы вывода времени
+var namedFormats = map[string]func(t time.Time) string{
+	"rfc1123":  func(t time.Time) string { return t.Format(time.RFC1123) },
+	"rfc3339":  func(t time.Time) string { return t.Format(time.RFC3339Nano) },
+	"iso8601":  func(t time.Time) string { return t.Format("2006-01-02T15:04:05.000Z07:00") },
+	"unix":     func(t time.Time) string { return strconv.FormatInt(t.Unix(), 10) },
+	"unixnano": func(t time.Time) string { return strconv.FormatInt(t.UnixNano(), 10) },
+}
+
+// strftimeDirectives сопоставляет директивам strftime форматирующие функции
+var strftimeDirectives = map[byte]func(t time.Time) string{
+	'a': func(t time.Time) string { return t.Format("Mon") },
+	'A': func(t time.Time) string { return t.Format("Monday") },
+	'b': func(t time.Time) string { return t.Format("Jan") },
+	'B': func(t time.Time) string { return t.Format("January") },
+	'd': func(t time.Time) string { return t.Format("02") },
+	'e': func(t time.Time) string { return t.Format("_2") },
+	'F': func(t time.Time) string { return t.Format("2006-01-02") },
+	'H': func(t time.Time) string { return t.Format("15") },
+	'I': func(t time.Time) string { return t.Format("03") },
+	'j': func(t time.Time) string { return t.Format("002") },
+	'm': func(t time.Time) string { return t.Format("01") },
+	'M': func(t time.Time) string { return t.Format("04") },
+	'N': func(t time.Time) string { return fmt.Sprintf("%09d", t.Nanosecond()) },
+	'p': func(t time.Time) string { return t.Format("PM") },
+	's': func(t time.Time) string { return strconv.FormatInt(t.Unix(), 10) },
+	'S': func(t time.Time) string { return t.Format("05") },
+	'T': func(t time.Time) string { return t.Format("15:04:05") },
+	'y': func(t time.Time) string { return t.Format("06") },
+	'Y': func(t time.Time) string { return t.Format("2006") },
+	'z': func(t time.Time) string { return t.Format("-0700") },
+	'Z': func(t time.Time) string { return t.Format("MST") },
+	'%': func(t time.Time) string { return "%" },
+}
+
+// strftime форматирует время по шаблону в стиле strftime
+func strftime(t time.Time, pattern string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '%' {
+			b.WriteByte(pattern[i])
+			continue
+		}
+		i++
+		if i == len(pattern) {
+			return "", fmt.Errorf("незавершенная директива в формате %q", pattern)
+		}
+		directive, ok := strftimeDirectives[pattern[i]]
+		if !ok {
+			return "", fmt.Errorf("неизвестная директива %%%c в формате %q", pattern[i], pattern)
+		}
+		b.WriteString(directive(t))
+	}
+	return b.String(), nil
+}
+
+// timeOutput описывает, в каких часовых поясах и в каком формате печатать время
+type timeOutput struct {
+	zones  []*time.Location
+	format func(t time.Time) string
+	plain  bool
+}
+
+// newTimeOutput разбирает список часовых поясов IANA через запятую и формат.
+// Формат может быть именованным (rfc1123, rfc3339, iso8601, unix, unixnano),
+// шаблоном strftime (если содержит '%') или макетом Go. При пустых
+// аргументах время печатается в локальном поясе в формате RFC1123 с подписью.
+func newTimeOutput(zones, format string) (timeOutput, error) {
+	out := timeOutput{plain: zones != "" || format != ""}
+
+	for _, name := range splitList(zones) {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return out, fmt.Errorf("неизвестный часовой пояс %q: %w", name, err)
+		}
+		out.zones = append(out.zones, loc)
+	}
+	if len(out.zones) == 0 {
+		out.zones = []*time.Location{time.Local}
+	}
+
+	switch {
+	case format == "":
+		out.format = namedFormats["rfc1123"]
+	case namedFormats[strings.ToLower(format)] != nil:
+		out.format = namedFormats[strings.ToLower(format)]
+	case strings.Contains(format, "%"):
+		if _, err := strftime(time.Time{}, format); err != nil {
+			return out, err
+		}
+		out.format = func(t time.Time) string {
+			s, _ := strftime(t, format)
+			return s
+		}
+	default:
+		out.format = func(t time.Time) string { return t.Format(format) }
+	}
+
+	return out, nil
+}
+
+// write печатает время во всех выбранных часовых поясах, по одному на строку
+func (o timeOutput) write(w io.Writer, t time.Time) error {
+	for _, loc := range o.zones {
+		value := o.format(t.In(loc))
+
+		var err error
+		switch {
+		case !o.plain:
+			_, err = fmt.Fprintf(w, "Текущее время: %s\n", value)
+		case len(o.zones) > 1:
+			_, err = fmt.Fprintf(w, "%s\t%s\n", loc, value)
+		default:
+			_, err = fmt.Fprintln(w, value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
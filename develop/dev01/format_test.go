@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestTimeOutput(t *testing.T) {
+	tm := time.Date(2023, time.March, 12, 15, 30, 0, 123000000, time.UTC)
+
+	tests := []struct {
+		name     string
+		zones    string
+		format   string
+		expected string
+	}{
+		{"UTC RFC1123", "UTC", "rfc1123", "Sun, 12 Mar 2023 15:30:00 UTC\n"},
+		{"ISO 8601", "Europe/Moscow", "iso8601", "2023-03-12T18:30:00.123+03:00\n"},
+		{"Unix", "UTC", "unix", "1678635000\n"},
+		{"Unix nano", "", "unixnano", "1678635000123000000\n"},
+		{"Go layout", "UTC", "02.01.2006 15:04", "12.03.2023 15:30\n"},
+		{"strftime", "Asia/Tokyo", "%Y-%m-%d %H:%M:%S.%N %z %%", "2023-03-13 00:30:00.123000000 +0900 %\n"},
+		{"Several zones", "UTC,Europe/Moscow", "%H:%M", "UTC\t15:30\nEurope/Moscow\t18:30\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := newTimeOutput(tt.zones, tt.format)
+			if err != nil {
+				t.Fatalf("newTimeOutput вернула ошибку: %v", err)
+			}
+			var buf bytes.Buffer
+			if err := out.write(&buf, tm); err != nil {
+				t.Fatalf("write вернула ошибку: %v", err)
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("Получено %q; want %q", buf.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestTimeOutputDefault(t *testing.T) {
+	out, err := newTimeOutput("", "")
+	if err != nil {
+		t.Fatalf("newTimeOutput вернула ошибку: %v", err)
+	}
+	tm := time.Date(2023, time.March, 12, 15, 30, 0, 0, time.Local)
+
+	var buf bytes.Buffer
+	out.write(&buf, tm)
+	if expected := "Текущее время: " + tm.Format(time.RFC1123) + "\n"; buf.String() != expected {
+		t.Errorf("Получено %q; want %q", buf.String(), expected)
+	}
+}
+
+func TestTimeOutputErrors(t *testing.T) {
+	if _, err := newTimeOutput("Mars/Olympus", ""); err == nil {
+		t.Error("Ожидалась ошибка для неизвестного часового пояса")
+	}
+	if _, err := newTimeOutput("", "%Q"); err == nil {
+		t.Error("Ожидалась ошибка для неизвестной директивы")
+	}
+	if _, err := newTimeOutput("", "%Y%"); err == nil {
+		t.Error("Ожидалась ошибка для незавершенной директивы")
+	}
+}
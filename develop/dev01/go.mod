@@ -2,9 +2,9 @@ module dev01
 
 go 1.22.2
 
-require github.com/beevik/ntp v1.4.3
-
 require (
-	golang.org/x/net v0.25.0 // indirect
-	golang.org/x/sys v0.20.0 // indirect
+	github.com/beevik/ntp v1.4.3
+	golang.org/x/sys v0.20.0
 )
+
+require golang.org/x/net v0.25.0 // indirect
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// offsetSample содержит одно измерение смещения часов
+type offsetSample struct {
+	At     time.Time
+	Offset time.Duration
+	RTT    time.Duration
+}
+
+// Monitor периодически опрашивает NTP сервер и хранит историю смещений
+type Monitor struct {
+	client     NTPClient
+	host       string
+	interval   time.Duration
+	maxBackoff time.Duration
+	size       int
+
+	mu        sync.Mutex
+	history   []offsetSample
+	stratum   uint8
+	successes uint64
+	failures  uint64
+}
+
+// NewMonitor создает монитор, опрашивающий host с интервалом interval,
+// увеличивающий интервал при ошибках не более чем до maxBackoff и
+// хранящий последние size измерений
+func NewMonitor(client NTPClient, host string, interval, maxBackoff time.Duration, size int) *Monitor {
+	return &Monitor{
+		client:     client,
+		host:       host,
+		interval:   interval,
+		maxBackoff: maxBackoff,
+		size:       size,
+	}
+}
+
+// Run опрашивает сервер до отмены контекста
+func (m *Monitor) Run(ctx context.Context) {
+	delay := m.interval
+	for {
+		err := m.poll()
+		if err != nil {
+			log.Print(errorMessage(err))
+		}
+		delay = m.nextDelay(delay, err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// nextDelay вычисляет задержку до следующего опроса: после успешного опроса
+// используется базовый интервал, после ошибки задержка удваивается
+func (m *Monitor) nextDelay(current time.Duration, err error) time.Duration {
+	if err == nil {
+		return m.interval
+	}
+	next := current * 2
+	if next > m.maxBackoff {
+		next = m.maxBackoff
+	}
+	return next
+}
+
+// poll выполняет один опрос сервера и сохраняет результат
+func (m *Monitor) poll() error {
+	resp, err := queryHost(m.client, m.host)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err != nil {
+		m.failures++
+		return err
+	}
+
+	m.successes++
+	m.stratum = resp.Stratum
+	m.history = append(m.history, offsetSample{At: now(), Offset: resp.ClockOffset, RTT: resp.RTT})
+	if len(m.history) > m.size {
+		m.history = m.history[len(m.history)-m.size:]
+	}
+	return nil
+}
+
+// ServeHTTP отдает метрики в текстовом формате Prometheus
+func (m *Monitor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	label := fmt.Sprintf("{server=%q}", m.host)
+	metric := func(name, kind, help string, value float64) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s%s %g\n", name, help, name, kind, name, label, value)
+	}
+
+	metric("ntp_queries_succeeded_total", "counter", "Number of successful NTP queries.", float64(m.successes))
+	metric("ntp_queries_failed_total", "counter", "Number of failed NTP queries.", float64(m.failures))
+	metric("ntp_offset_history_samples", "gauge", "Number of offsets in the rolling history.", float64(len(m.history)))
+
+	if len(m.history) == 0 {
+		return
+	}
+
+	last := m.history[len(m.history)-1]
+	metric("ntp_offset_seconds", "gauge", "Last measured clock offset.", last.Offset.Seconds())
+	metric("ntp_rtt_seconds", "gauge", "Last measured round-trip delay.", last.RTT.Seconds())
+	metric("ntp_stratum", "gauge", "Last reported server stratum.", float64(m.stratum))
+	metric("ntp_last_success_timestamp_seconds", "gauge", "Unix time of the last successful query.", float64(last.At.UnixNano())/1e9)
+
+	mean, stddev, lo, hi := offsetStats(m.history)
+	metric("ntp_offset_mean_seconds", "gauge", "Mean clock offset over the rolling history.", mean)
+	metric("ntp_offset_stddev_seconds", "gauge", "Standard deviation of clock offset over the rolling history.", stddev)
+	metric("ntp_offset_min_seconds", "gauge", "Minimum clock offset over the rolling history.", lo)
+	metric("ntp_offset_max_seconds", "gauge", "Maximum clock offset over the rolling history.", hi)
+}
+
+// offsetStats вычисляет среднее, стандартное отклонение, минимум и максимум смещений в секундах
+func offsetStats(history []offsetSample) (mean, stddev, lo, hi float64) {
+	lo, hi = math.Inf(1), math.Inf(-1)
+	for _, s := range history {
+		v := s.Offset.Seconds()
+		mean += v
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+	}
+	mean /= float64(len(history))
+
+	for _, s := range history {
+		d := s.Offset.Seconds() - mean
+		stddev += d * d
+	}
+	stddev = math.Sqrt(stddev / float64(len(history)))
+
+	return mean, stddev, lo, hi
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMonitorNextDelay(t *testing.T) {
+	m := NewMonitor(nil, defaultHost, time.Second, 5*time.Second, 10)
+	failure := errors.New("таймаут")
+
+	delay := time.Second
+	var got []time.Duration
+	for i := 0; i < 4; i++ {
+		delay = m.nextDelay(delay, failure)
+		got = append(got, delay)
+	}
+	expected := []time.Duration{2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Задержка после %d ошибок: %v; want %v", i+1, got[i], expected[i])
+		}
+	}
+
+	if d := m.nextDelay(delay, nil); d != time.Second {
+		t.Errorf("После успешного опроса ожидался интервал 1s, получено %v", d)
+	}
+}
+
+func TestMonitorMetrics(t *testing.T) {
+	client := &ScriptedNTPClient{replies: map[string][]ScriptedReply{"a": {
+		{Offset: 10 * time.Millisecond},
+		{Err: errors.New("таймаут")},
+		{Offset: 20 * time.Millisecond},
+		{Offset: 30 * time.Millisecond},
+		{Offset: 40 * time.Millisecond},
+	}}}
+	m := NewMonitor(client, "a", time.Second, time.Minute, 3)
+	for i := 0; i < 5; i++ {
+		m.poll()
+	}
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, line := range []string{
+		`ntp_queries_succeeded_total{server="a"} 4`,
+		`ntp_queries_failed_total{server="a"} 1`,
+		`ntp_offset_history_samples{server="a"} 3`,
+		`ntp_offset_seconds{server="a"} 0.04`,
+		`ntp_offset_mean_seconds{server="a"} 0.03`,
+		`ntp_offset_min_seconds{server="a"} 0.02`,
+		`ntp_stratum{server="a"} 2`,
+		`# TYPE ntp_rtt_seconds gauge`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("Метрики не содержат строку %q:\n%s", line, body)
+		}
+	}
+}
+
+func TestParseMonitorFlags(t *testing.T) {
+	mf, err := parseMonitorFlags([]string{"-interval", "10s", "-max-backoff", "10s", "-history", "1"})
+	if err != nil || mf.interval != 10*time.Second || mf.history != 1 {
+		t.Errorf("parseMonitorFlags() = %+v, %v", mf, err)
+	}
+
+	for _, args := range [][]string{
+		{"-history", "0"},
+		{"-history", "-1"},
+		{"-interval", "0s"},
+		{"-interval", "-5s"},
+		{"-interval", "2m", "-max-backoff", "1m"},
+	} {
+		if _, err := parseMonitorFlags(args); err == nil {
+			t.Errorf("parseMonitorFlags(%q): ожидалась ошибка", args)
+		}
+	}
+}
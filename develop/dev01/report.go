@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/beevik/ntp"
+)
+
+// timeReport содержит подробный отчет об ответе NTP сервера
+type timeReport struct {
+	Host           string    `json:"host"`
+	Time           time.Time `json:"time"`
+	Offset         float64   `json:"offset_seconds"`
+	RTT            float64   `json:"rtt_seconds"`
+	Stratum        uint8     `json:"stratum"`
+	ReferenceID    string    `json:"reference_id"`
+	Leap           string    `json:"leap"`
+	RootDispersion float64   `json:"root_dispersion_seconds"`
+}
+
+// newTimeReport формирует отчет по ответу сервера, поправляя локальное время на смещение
+func newTimeReport(host string, resp *ntp.Response) timeReport {
+	return timeReport{
+		Host:           host,
+		Time:           now().Add(resp.ClockOffset),
+		Offset:         resp.ClockOffset.Seconds(),
+		RTT:            resp.RTT.Seconds(),
+		Stratum:        resp.Stratum,
+		ReferenceID:    resp.ReferenceString(),
+		Leap:           leapString(resp.Leap),
+		RootDispersion: resp.RootDispersion.Seconds(),
+	}
+}
+
+// leapString возвращает текстовое представление индикатора високосной секунды
+func leapString(li ntp.LeapIndicator) string {
+	switch li {
+	case ntp.LeapNoWarning:
+		return "none"
+	case ntp.LeapAddSecond:
+		return "add"
+	case ntp.LeapDelSecond:
+		return "delete"
+	case ntp.LeapNotInSync:
+		return "unsynchronized"
+	}
+	return fmt.Sprintf("unknown(%d)", li)
+}
+
+// writeReport печатает отчет в текстовом виде или в формате JSON
+func writeReport(w io.Writer, r timeReport, asJSON bool) error {
+	if asJSON {
+		return json.NewEncoder(w).Encode(r)
+	}
+
+	_, err := fmt.Fprintf(w, "Сервер:          %s\n"+
+		"Текущее время:   %s\n"+
+		"Смещение:        %.6f с\n"+
+		"Задержка (RTT):  %.6f с\n"+
+		"Стратум:         %d\n"+
+		"Идентификатор:   %s\n"+
+		"Високосная сек.: %s\n"+
+		"Дисперсия:       %.6f с\n",
+		r.Host, r.Time.Format(time.RFC1123), r.Offset, r.RTT,
+		r.Stratum, r.ReferenceID, r.Leap, r.RootDispersion)
+	return err
+}
+
+// writeReports печатает отчеты нескольких серверов: в текстовом виде они
+// разделяются пустой строкой, в JSON каждый отчет занимает отдельную строку
+func writeReports(w io.Writer, reports []timeReport, asJSON bool) error {
+	for i, r := range reports {
+		if i > 0 && !asJSON {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		if err := writeReport(w, r, asJSON); err != nil {
+			return err
+		}
+	}
+	return nil
+}
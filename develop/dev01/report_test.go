@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewTimeReport(t *testing.T) {
+	base := time.Date(2023, time.March, 12, 15, 30, 0, 0, time.UTC)
+	now = func() time.Time { return base }
+	defer func() { now = time.Now }()
+
+	resp, err := MockNTPClient{}.Query(defaultHost)
+	if err != nil {
+		t.Fatalf("Query вернула ошибку: %v", err)
+	}
+
+	r := newTimeReport(defaultHost, resp)
+	expected := timeReport{
+		Host:           defaultHost,
+		Time:           base.Add(15 * time.Millisecond),
+		Offset:         0.015,
+		RTT:            0.04,
+		Stratum:        2,
+		ReferenceID:    "192.168.0.1",
+		Leap:           "none",
+		RootDispersion: 0.002,
+	}
+	if r != expected {
+		t.Errorf("newTimeReport() = %+v; want %+v", r, expected)
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	r := timeReport{Host: "a", Offset: 0.5, Stratum: 1, ReferenceID: ".GPS.", Leap: "none"}
+
+	var buf bytes.Buffer
+	if err := writeReport(&buf, r, true); err != nil {
+		t.Fatalf("writeReport вернула ошибку: %v", err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("Некорректный JSON %q: %v", buf.String(), err)
+	}
+	if got["offset_seconds"] != 0.5 || got["reference_id"] != ".GPS." {
+		t.Errorf("Неверный JSON отчет: %v", got)
+	}
+
+	buf.Reset()
+	if err := writeReport(&buf, r, false); err != nil {
+		t.Fatalf("writeReport вернула ошибку: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Смещение:        0.500000 с") {
+		t.Errorf("Текстовый отчет не содержит смещение: %q", buf.String())
+	}
+}
+
+func TestWriteReports(t *testing.T) {
+	reports := []timeReport{
+		{Host: "a", Offset: 0.5, Stratum: 1, Leap: "none"},
+		{Host: "b", Offset: -0.25, Stratum: 2, Leap: "none"},
+	}
+
+	var buf bytes.Buffer
+	if err := writeReports(&buf, reports, true); err != nil {
+		t.Fatalf("writeReports вернула ошибку: %v", err)
+	}
+	dec := json.NewDecoder(&buf)
+	for _, want := range reports {
+		var got timeReport
+		if err := dec.Decode(&got); err != nil {
+			t.Fatalf("Некорректный JSON: %v", err)
+		}
+		if got.Host != want.Host || got.Offset != want.Offset {
+			t.Errorf("Неверный JSON отчет: %+v; want %+v", got, want)
+		}
+	}
+
+	buf.Reset()
+	if err := writeReports(&buf, reports, false); err != nil {
+		t.Fatalf("writeReports вернула ошибку: %v", err)
+	}
+	text := buf.String()
+	if !strings.Contains(text, "Сервер:          a\n") || !strings.Contains(text, "\n\nСервер:          b\n") {
+		t.Errorf("Текстовый отчет не содержит оба сервера: %q", text)
+	}
+}
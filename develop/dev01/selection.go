@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// errNoMajority возвращается, если большинство серверов не сошлось в оценке времени
+var errNoMajority = errors.New("большинство серверов не согласовано между собой")
+
+// errNoResponses возвращается, если ни один сервер не ответил
+var errNoResponses = errors.New("ни один сервер не ответил")
+
+// now возвращает локальное время; подменяется в тестах
+var now = time.Now
+
+// serverSample содержит результат опроса одного NTP сервера
+type serverSample struct {
+	Host   string
+	Offset time.Duration
+	Err    error
+}
+
+// selection содержит результат отбора серверов по алгоритму Марзулло
+type selection struct {
+	Offset       time.Duration
+	Low, High    time.Duration
+	Truechimers  []string
+	Falsetickers []string
+	Failed       []string
+}
+
+// edge представляет границу интервала доверия сервера
+type edge struct {
+	offset time.Duration
+	kind   int // -1 — начало интервала, +1 — конец
+}
+
+// queryServers конкурентно опрашивает все серверы и возвращает смещения
+// их времени относительно локальных часов в порядке следования hosts
+func queryServers(client NTPClient, hosts []string) []serverSample {
+	samples := make([]serverSample, len(hosts))
+
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		wg.Add(1)
+		go func(i int, host string) {
+			defer wg.Done()
+			resp, err := queryHost(client, host)
+			samples[i] = serverSample{Host: host, Err: err}
+			if err == nil {
+				samples[i].Offset = resp.ClockOffset
+			}
+		}(i, host)
+	}
+	wg.Wait()
+
+	return samples
+}
+
+// selectOffset отбрасывает фальшивые серверы (falsetickers) и вычисляет
+// общее смещение как середину наибольшего пересечения интервалов
+// [offset-tolerance, offset+tolerance]. Пересечение должно покрывать
+// большинство успешно ответивших серверов.
+func selectOffset(samples []serverSample, tolerance time.Duration) (selection, error) {
+	var sel selection
+	var edges []edge
+	var ok []serverSample
+	for _, s := range samples {
+		if s.Err != nil {
+			sel.Failed = append(sel.Failed, s.Host)
+			continue
+		}
+		ok = append(ok, s)
+		edges = append(edges,
+			edge{s.Offset - tolerance, -1},
+			edge{s.Offset + tolerance, +1},
+		)
+	}
+	if len(ok) == 0 {
+		return sel, errNoResponses
+	}
+
+	// Начала интервалов идут раньше концов, чтобы касающиеся интервалы
+	// считались пересекающимися
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].offset != edges[j].offset {
+			return edges[i].offset < edges[j].offset
+		}
+		return edges[i].kind < edges[j].kind
+	})
+
+	var best, count int
+	for i, e := range edges {
+		count -= e.kind
+		if count > best {
+			best = count
+			sel.Low = e.offset
+			sel.High = edges[i+1].offset
+		}
+	}
+
+	if best <= len(ok)/2 {
+		return sel, errNoMajority
+	}
+
+	sel.Offset = sel.Low + (sel.High-sel.Low)/2
+	for _, s := range ok {
+		if s.Offset-tolerance <= sel.Low && s.Offset+tolerance >= sel.High {
+			sel.Truechimers = append(sel.Truechimers, s.Host)
+		} else {
+			sel.Falsetickers = append(sel.Falsetickers, s.Host)
+		}
+	}
+
+	return sel, nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSelectOffset(t *testing.T) {
+	base := time.Date(2023, time.March, 12, 15, 30, 0, 0, time.UTC)
+	now = func() time.Time { return base }
+	defer func() { now = time.Now }()
+
+	client := &ScriptedNTPClient{
+		base: base,
+		replies: map[string][]ScriptedReply{
+			"a": {{Offset: 10 * time.Millisecond}},
+			"b": {{Offset: 30 * time.Millisecond}},
+			"c": {{Offset: 20 * time.Millisecond}},
+			"d": {{Offset: 5 * time.Second}},
+		},
+	}
+
+	samples := queryServers(client, []string{"a", "b", "c", "d", "e"})
+	sel, err := selectOffset(samples, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("selectOffset вернула ошибку: %v", err)
+	}
+
+	if sel.Low != -20*time.Millisecond || sel.High != 60*time.Millisecond {
+		t.Errorf("Ожидалось пересечение [-20ms, 60ms], получено [%v, %v]", sel.Low, sel.High)
+	}
+	if sel.Offset != 20*time.Millisecond {
+		t.Errorf("Ожидалось смещение 20ms, получено %v", sel.Offset)
+	}
+	if !reflect.DeepEqual(sel.Truechimers, []string{"a", "b", "c"}) {
+		t.Errorf("Неверный список согласованных серверов: %v", sel.Truechimers)
+	}
+	if !reflect.DeepEqual(sel.Falsetickers, []string{"d"}) {
+		t.Errorf("Неверный список отброшенных серверов: %v", sel.Falsetickers)
+	}
+	if !reflect.DeepEqual(sel.Failed, []string{"e"}) {
+		t.Errorf("Неверный список недоступных серверов: %v", sel.Failed)
+	}
+}
+
+func TestSelectOffsetNoMajority(t *testing.T) {
+	samples := []serverSample{
+		{Host: "a", Offset: 0},
+		{Host: "b", Offset: time.Second},
+		{Host: "c", Offset: 2 * time.Second},
+	}
+
+	if _, err := selectOffset(samples, 100*time.Millisecond); !errors.Is(err, errNoMajority) {
+		t.Errorf("Ожидалась ошибка %v, получено %v", errNoMajority, err)
+	}
+}
+
+func TestSelectOffsetNoResponses(t *testing.T) {
+	samples := []serverSample{
+		{Host: "a", Err: errors.New("таймаут")},
+		{Host: "b", Err: errors.New("таймаут")},
+	}
+
+	if _, err := selectOffset(samples, 100*time.Millisecond); !errors.Is(err, errNoResponses) {
+		t.Errorf("Ожидалась ошибка %v, получено %v", errNoResponses, err)
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	got := splitList(" a.pool.ntp.org, ,b.pool.ntp.org,")
+	expected := []string{"a.pool.ntp.org", "b.pool.ntp.org"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("splitList() = %v; want %v", got, expected)
+	}
+}
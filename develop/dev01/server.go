@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"log"
+	"net"
+	"sync"
+	"time"
+)
+
+// Параметры NTP пакета
+const (
+	packetSize    = 48
+	modeClient    = 3
+	modeServer    = 4
+	leapNotInSync = 3
+	maxStratum    = 16
+)
+
+// ntpEpoch — начало эпохи NTP
+var ntpEpoch = time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// toNtpTime переводит время в 64-битный формат NTP (32.32 с фиксированной точкой)
+func toNtpTime(t time.Time) uint64 {
+	d := t.Sub(ntpEpoch)
+	sec := uint64(d / time.Second)
+	frac := uint64(d%time.Second) << 32 / uint64(time.Second)
+	return sec<<32 | frac
+}
+
+// SNTPServer отвечает на запросы NTP клиентов версий 3 и 4 временем из Clock
+type SNTPServer struct {
+	// Clock возвращает время, сообщаемое клиентам
+	Clock func() time.Time
+	// Stratum — стратум сервера; 0 и значения от 16 означают, что часы не
+	// синхронизированы
+	Stratum uint8
+	// ReferenceID — идентификатор источника времени
+	ReferenceID uint32
+	// ReferenceTime возвращает время последней синхронизации часов
+	ReferenceTime func() time.Time
+
+	// mu защищает Stratum от изменения во время обслуживания запросов
+	mu sync.RWMutex
+}
+
+// NewLocalServer создает сервер, раздающий время локальных часов
+func NewLocalServer() *SNTPServer {
+	return &SNTPServer{
+		Clock:         time.Now,
+		Stratum:       1,
+		ReferenceID:   binary.BigEndian.Uint32([]byte("LOCL")),
+		ReferenceTime: time.Now,
+	}
+}
+
+// Serve принимает запросы из conn и отвечает на них, пока соединение не
+// закрыто. Ошибка отправки ответа одному клиенту не останавливает сервер.
+func (s *SNTPServer) Serve(conn net.PacketConn) error {
+	buf := make([]byte, 1024)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		recv := s.Clock()
+
+		resp, ok := s.respond(buf[:n], recv)
+		if !ok {
+			continue
+		}
+		if _, err := conn.WriteTo(resp, addr); err != nil {
+			log.Printf("Ошибка отправки ответа клиенту %s: %v", addr, err)
+		}
+	}
+}
+
+// respond формирует ответ на запрос клиента; некорректные запросы игнорируются
+func (s *SNTPServer) respond(req []byte, recv time.Time) ([]byte, bool) {
+	if len(req) < packetSize {
+		return nil, false
+	}
+	version := req[0] >> 3 & 0x7
+	mode := req[0] & 0x7
+	if mode != modeClient || version < 3 || version > 4 {
+		return nil, false
+	}
+
+	s.mu.RLock()
+	stratum := s.Stratum
+	s.mu.RUnlock()
+
+	var leap uint8
+	if stratum == 0 || stratum >= maxStratum {
+		leap = leapNotInSync
+	}
+
+	resp := make([]byte, packetSize)
+	resp[0] = leap<<6 | version<<3 | modeServer
+	resp[1] = stratum
+	resp[2] = req[2]      // Poll повторяет значение клиента
+	resp[3] = uint8(0xec) // Precision: 2^-20 с
+	binary.BigEndian.PutUint32(resp[12:], s.ReferenceID)
+	binary.BigEndian.PutUint64(resp[16:], toNtpTime(s.ReferenceTime()))
+	copy(resp[24:32], req[40:48]) // Origin = Transmit клиента
+	binary.BigEndian.PutUint64(resp[32:], toNtpTime(recv))
+	binary.BigEndian.PutUint64(resp[40:], toNtpTime(s.Clock()))
+
+	return resp, true
+}
+
+// setStratum меняет стратум работающего сервера
+func (s *SNTPServer) setStratum(stratum uint8) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Stratum = stratum
+}
+
+// upstreamClock представляет локальные часы, скорректированные по вышестоящему серверу
+type upstreamClock struct {
+	mu       sync.RWMutex
+	offset   time.Duration
+	syncedAt time.Time
+}
+
+// Now возвращает локальное время с поправкой на последнее измеренное смещение
+func (c *upstreamClock) Now() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return time.Now().Add(c.offset)
+}
+
+// LastSync возвращает время последней синхронизации
+func (c *upstreamClock) LastSync() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.syncedAt
+}
+
+// sync запрашивает вышестоящий сервер и обновляет смещение
+func (c *upstreamClock) sync(client NTPClient, host string) (uint8, error) {
+	resp, err := client.Query(host)
+	if err != nil {
+		return 0, err
+	}
+	if err := resp.Validate(); err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.offset = resp.ClockOffset
+	c.syncedAt = time.Now().Add(resp.ClockOffset)
+	return resp.Stratum, nil
+}
+
+// NewUpstreamServer создает сервер, раздающий время, скорректированное по
+// вышестоящему серверу host, и возвращает функцию повторной синхронизации.
+// Каждая успешная синхронизация обновляет стратум сервера. После maxFailures
+// неудачных синхронизаций подряд сервер отвечает с признаком "часы не
+// синхронизированы" (leap=3) до следующей успешной; 0 отключает проверку.
+func NewUpstreamServer(client NTPClient, host string, maxFailures int) (*SNTPServer, func() error, error) {
+	clock := &upstreamClock{}
+	stratum, err := clock.sync(client, host)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s := &SNTPServer{
+		Clock:         clock.Now,
+		Stratum:       stratum + 1,
+		ReferenceID:   referenceID(host),
+		ReferenceTime: clock.LastSync,
+	}
+	failures := 0
+	resync := func() error {
+		stratum, err := clock.sync(client, host)
+		if err != nil {
+			failures++
+			if maxFailures > 0 && failures >= maxFailures {
+				s.setStratum(maxStratum)
+			}
+			return err
+		}
+		failures = 0
+		s.setStratum(stratum + 1)
+		return nil
+	}
+	return s, resync, nil
+}
+
+// referenceID возвращает IPv4 адрес вышестоящего сервера в виде идентификатора
+func referenceID(host string) uint32 {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	addr, err := net.ResolveIPAddr("ip", host)
+	if err != nil {
+		return 0
+	}
+	if ip4 := addr.IP.To4(); ip4 != nil {
+		return binary.BigEndian.Uint32(ip4)
+	}
+	return 0
+}
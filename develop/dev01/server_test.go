@@ -0,0 +1,195 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// startServer запускает сервер на loopback интерфейсе и возвращает его адрес
+func startServer(t *testing.T, s *SNTPServer) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Ошибка запуска сервера: %v", err)
+	}
+	done := make(chan error)
+	go func() { done <- s.Serve(conn) }()
+	t.Cleanup(func() {
+		conn.Close()
+		if err := <-done; err != nil {
+			t.Errorf("Serve вернула ошибку: %v", err)
+		}
+	})
+	return conn.LocalAddr().String()
+}
+
+func TestLocalServer(t *testing.T) {
+	addr := startServer(t, NewLocalServer())
+
+	resp, err := RealNTPClient{}.Query(addr)
+	if err != nil {
+		t.Fatalf("Query вернула ошибку: %v", err)
+	}
+	if err := resp.Validate(); err != nil {
+		t.Fatalf("Ответ сервера некорректен: %v", err)
+	}
+	if resp.Stratum != 1 || resp.ReferenceString() != ".LOCL." {
+		t.Errorf("Ожидался стратум 1 и идентификатор .LOCL., получено %d и %s", resp.Stratum, resp.ReferenceString())
+	}
+	if resp.ClockOffset.Abs() > 50*time.Millisecond {
+		t.Errorf("Слишком большое смещение для локальных часов: %v", resp.ClockOffset)
+	}
+}
+
+func TestServerClockOffset(t *testing.T) {
+	s := NewLocalServer()
+	s.Clock = func() time.Time { return time.Now().Add(time.Hour) }
+	s.ReferenceTime = s.Clock
+	addr := startServer(t, s)
+
+	tm, err := RealNTPClient{}.Time(addr)
+	if err != nil {
+		t.Fatalf("Time вернула ошибку: %v", err)
+	}
+	if d := tm.Sub(time.Now().Add(time.Hour)).Abs(); d > 50*time.Millisecond {
+		t.Errorf("Ожидалось время со смещением в час, расхождение %v", d)
+	}
+}
+
+func TestUpstreamServer(t *testing.T) {
+	upstream := startServer(t, NewLocalServer())
+
+	s, resync, err := NewUpstreamServer(RealNTPClient{}, upstream, 3)
+	if err != nil {
+		t.Fatalf("NewUpstreamServer вернула ошибку: %v", err)
+	}
+	if err := resync(); err != nil {
+		t.Fatalf("Ошибка повторной синхронизации: %v", err)
+	}
+	addr := startServer(t, s)
+
+	resp, err := RealNTPClient{}.Query(addr)
+	if err != nil {
+		t.Fatalf("Query вернула ошибку: %v", err)
+	}
+	if err := resp.Validate(); err != nil {
+		t.Fatalf("Ответ сервера некорректен: %v", err)
+	}
+	if resp.Stratum != 2 || resp.ReferenceString() != "127.0.0.1" {
+		t.Errorf("Ожидался стратум 2 и идентификатор 127.0.0.1, получено %d и %s", resp.Stratum, resp.ReferenceString())
+	}
+}
+
+func TestRespondIgnoresInvalidRequests(t *testing.T) {
+	s := NewLocalServer()
+	req := make([]byte, packetSize)
+
+	req[0] = 4<<3 | modeServer
+	if _, ok := s.respond(req, time.Now()); ok {
+		t.Error("Ожидалось, что запрос в режиме сервера будет проигнорирован")
+	}
+	req[0] = 2<<3 | modeClient
+	if _, ok := s.respond(req, time.Now()); ok {
+		t.Error("Ожидалось, что запрос версии 2 будет проигнорирован")
+	}
+	if _, ok := s.respond(req[:10], time.Now()); ok {
+		t.Error("Ожидалось, что короткий запрос будет проигнорирован")
+	}
+}
+
+func TestUpstreamServerResync(t *testing.T) {
+	failure := ScriptedReply{Err: errors.New("таймаут")}
+	client := &ScriptedNTPClient{replies: map[string][]ScriptedReply{
+		"upstream": {{Stratum: 2}, {Stratum: 3}, failure, failure, failure, {Stratum: 1}},
+	}}
+	s, resync, err := NewUpstreamServer(client, "upstream", 2)
+	if err != nil {
+		t.Fatalf("NewUpstreamServer вернула ошибку: %v", err)
+	}
+
+	req := make([]byte, packetSize)
+	req[0] = 4<<3 | modeClient
+	check := func(step string, stratum, leap uint8) {
+		t.Helper()
+		resp, ok := s.respond(req, time.Now())
+		if !ok {
+			t.Fatalf("%s: сервер не ответил", step)
+		}
+		if resp[1] != stratum || resp[0]>>6 != leap {
+			t.Errorf("%s: стратум %d и leap %d; want %d и %d", step, resp[1], resp[0]>>6, stratum, leap)
+		}
+	}
+
+	check("после запуска", 3, 0)
+	if err := resync(); err != nil {
+		t.Fatalf("Ошибка повторной синхронизации: %v", err)
+	}
+	check("после смены стратума", 4, 0)
+
+	// Одна неудача не меняет ответ, вторая подряд — признак рассинхронизации
+	resync()
+	check("после первой неудачи", 4, 0)
+	resync()
+	check("после второй неудачи", maxStratum, leapNotInSync)
+	resync()
+	check("после третьей неудачи", maxStratum, leapNotInSync)
+
+	if err := resync(); err != nil {
+		t.Fatalf("Ошибка повторной синхронизации: %v", err)
+	}
+	check("после восстановления", 2, 0)
+}
+
+// flakyConn отдает заранее заданные запросы и не может отправить первый ответ
+type flakyConn struct {
+	net.PacketConn
+	requests [][]byte
+	writes   int
+	sent     [][]byte
+}
+
+// ReadFrom возвращает очередной запрос, а после последнего — net.ErrClosed
+func (c *flakyConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	if len(c.requests) == 0 {
+		return 0, nil, net.ErrClosed
+	}
+	n := copy(b, c.requests[0])
+	c.requests = c.requests[1:]
+	return n, &net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 123}, nil
+}
+
+// WriteTo возвращает ошибку для первого ответа и запоминает остальные
+func (c *flakyConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	c.writes++
+	if c.writes == 1 {
+		return 0, errors.New("сеть недоступна")
+	}
+	c.sent = append(c.sent, append([]byte(nil), b...))
+	return len(b), nil
+}
+
+func TestServeContinuesAfterWriteError(t *testing.T) {
+	req := make([]byte, packetSize)
+	req[0] = 4<<3 | modeClient
+	conn := &flakyConn{requests: [][]byte{req, req}}
+
+	if err := NewLocalServer().Serve(conn); err != nil {
+		t.Fatalf("Serve вернула ошибку: %v", err)
+	}
+	if conn.writes != 2 || len(conn.sent) != 1 {
+		t.Errorf("Ожидался ответ на второй запрос после ошибки отправки первого: попыток %d, отправлено %d", conn.writes, len(conn.sent))
+	}
+}
+
+func TestParseServeFlags(t *testing.T) {
+	if sf, err := parseServeFlags([]string{"-poll", "30s"}); err != nil || sf.poll != 30*time.Second {
+		t.Errorf("parseServeFlags() = %+v, %v", sf, err)
+	}
+	for _, args := range [][]string{{"-poll", "0s"}, {"-poll", "-1m"}, {"-max-failures", "-1"}} {
+		if _, err := parseServeFlags(args); err == nil {
+			t.Errorf("parseServeFlags(%q): ожидалась ошибка", args)
+		}
+	}
+}
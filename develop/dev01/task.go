@@ -13,17 +13,86 @@ package main
 */
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"log"
+	"net"
+	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/beevik/ntp"
 )
 
+// defaultHost — NTP сервер, используемый по умолчанию
+const defaultHost = "0.beevik-ntp.pool.ntp.org"
+
+// Коды выхода программы
+const (
+	exitNTPError = 1 // ошибка получения времени
+	exitUsage    = 2 // некорректные аргументы командной строки
+	exitOutput   = 3 // ошибка вывода результата
+	exitServer   = 4 // ошибка запуска или работы сервера
+	exitClock    = 5 // ошибка коррекции локальных часов
+	exitPanic    = 6 // смещение превышает порог паники
+
+	exitDNS             = 10 // имя сервера не разрешается
+	exitTimeout         = 11 // сервер не ответил вовремя
+	exitKissRate        = 12 // Kiss-o'-Death RATE: слишком частые запросы
+	exitKissDeny        = 13 // Kiss-o'-Death DENY/RSTR: доступ запрещен
+	exitUnsynchronized  = 14 // часы сервера не синхронизированы
+	exitInvalidResponse = 15 // некорректный ответ сервера
+)
+
+// TimeFlags содержит флаги командной строки
+type TimeFlags struct {
+	servers   []string
+	tolerance time.Duration
+	verbose   bool
+	json      bool
+	output    timeOutput
+}
+
+// parseFlags парсит флаги командной строки
+func parseFlags() (TimeFlags, error) {
+	var tf TimeFlags
+	var servers, zones, format string
+
+	flag.StringVar(&servers, "servers", defaultHost, "список NTP серверов через запятую")
+	flag.DurationVar(&tf.tolerance, "tolerance", 100*time.Millisecond, "допустимое расхождение сервера при отборе")
+	flag.BoolVar(&tf.verbose, "verbose", false, "печатать смещение, задержку, стратум и другие параметры ответа")
+	flag.BoolVar(&tf.json, "json", false, "печатать параметры ответа в формате JSON")
+	flag.StringVar(&zones, "tz", "", "список часовых поясов IANA через запятую")
+	flag.StringVar(&format, "format", "", "формат времени: rfc1123, rfc3339, iso8601, unix, unixnano, шаблон strftime или макет Go")
+
+	flag.Parse()
+
+	tf.servers = splitList(servers)
+
+	var err error
+	tf.output, err = newTimeOutput(zones, format)
+
+	return tf, err
+}
+
+// splitList разбирает список значений, разделенных запятыми; пустые
+// элементы пропускаются
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // NTPClient представляет интерфейс для получения времени с NTP сервера
 type NTPClient interface {
 	Time(host string) (time.Time, error)
+	Query(host string) (*ntp.Response, error)
 }
 
 // RealNTPClient представляет реальный NTP клиент
@@ -34,26 +103,313 @@ func (c RealNTPClient) Time(host string) (time.Time, error) {
 	return ntp.Time(host)
 }
 
+// Query возвращает полный ответ указанного NTP сервера
+func (c RealNTPClient) Query(host string) (*ntp.Response, error) {
+	return ntp.Query(host)
+}
+
 // getCurrentTime получает текущее время с NTP сервера через предоставленный клиент
 func getCurrentTime(client NTPClient) (time.Time, error) {
-	return client.Time("0.beevik-ntp.pool.ntp.org")
+	return client.Time(defaultHost)
 }
 
 // printCurrentTime печатает текущее время, используя предоставленный клиент NTP
-func printCurrentTime(client NTPClient) {
-	currentTime, err := getCurrentTime(client)
+func printCurrentTime(client NTPClient, out timeOutput) {
+	printHostTime(client, defaultHost, out)
+}
+
+// printHostTime печатает текущее время, полученное с указанного сервера
+func printHostTime(client NTPClient, host string, out timeOutput) {
+	resp, err := queryHost(client, host)
 	if err != nil {
 		// Логируем ошибку в STDERR в случае ее возникновения
-		log.Printf("Ошибка получения времени с NTP сервера: %v", err)
-		// Возвращаем ненулевой код выхода
-		os.Exit(1)
+		log.Print(errorMessage(err))
+		// Возвращаем ненулевой код выхода, соответствующий классу ошибки
+		os.Exit(exitCode(err))
 	}
 
 	// Печатаем текущее время
-	fmt.Printf("Текущее время: %s\n", currentTime.Format(time.RFC1123))
+	if err := out.write(os.Stdout, now().Add(resp.ClockOffset)); err != nil {
+		log.Printf("Ошибка вывода времени: %v", err)
+		os.Exit(exitOutput)
+	}
+}
+
+// selectServers опрашивает серверы, печатает ошибки недоступных и
+// отброшенных и возвращает согласованное смещение. Если выбрать время не
+// удалось, программа завершается с кодом, соответствующим ошибкам серверов.
+func selectServers(client NTPClient, hosts []string, tolerance time.Duration) selection {
+	samples := queryServers(client, hosts)
+	for _, s := range samples {
+		if s.Err != nil {
+			log.Print(errorMessage(s.Err))
+		}
+	}
+
+	sel, err := selectOffset(samples, tolerance)
+	if err != nil {
+		log.Printf("Ошибка выбора времени: %v", err)
+		os.Exit(selectionExitCode(samples, err))
+	}
+	if len(sel.Falsetickers) > 0 {
+		log.Printf("Отброшены серверы: %s", strings.Join(sel.Falsetickers, ", "))
+	}
+	return sel
+}
+
+// printCombinedTime опрашивает несколько серверов, отбрасывает фальшивые
+// и печатает согласованное время вместе со списком согласившихся серверов
+func printCombinedTime(client NTPClient, hosts []string, tolerance time.Duration, out timeOutput) {
+	sel := selectServers(client, hosts, tolerance)
+
+	if err := out.write(os.Stdout, now().Add(sel.Offset)); err != nil {
+		log.Printf("Ошибка вывода времени: %v", err)
+		os.Exit(exitOutput)
+	}
+	fmt.Printf("Согласованные серверы: %s\n", strings.Join(sel.Truechimers, ", "))
+}
+
+// printHostReports печатает подробные отчеты об ответах всех указанных
+// серверов. Недоступные серверы не прерывают вывод: ошибки печатаются в
+// STDERR, а код выхода соответствует первой из них.
+func printHostReports(client NTPClient, hosts []string, asJSON bool) {
+	var reports []timeReport
+	var firstErr error
+	for _, host := range hosts {
+		resp, err := queryHost(client, host)
+		if err != nil {
+			log.Print(errorMessage(err))
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		reports = append(reports, newTimeReport(host, resp))
+	}
+
+	if err := writeReports(os.Stdout, reports, asJSON); err != nil {
+		log.Printf("Ошибка вывода отчета: %v", err)
+		os.Exit(exitOutput)
+	}
+	if firstErr != nil {
+		os.Exit(exitCode(firstErr))
+	}
+}
+
+// ServeFlags содержит флаги режима сервера
+type ServeFlags struct {
+	listen      string
+	upstream    string
+	poll        time.Duration
+	maxFailures int
+}
+
+// parseServeFlags парсит и проверяет флаги режима сервера
+func parseServeFlags(args []string) (ServeFlags, error) {
+	var sf ServeFlags
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+
+	fs.StringVar(&sf.listen, "listen", ":123", "UDP адрес для приема запросов")
+	fs.StringVar(&sf.upstream, "upstream", "", "вышестоящий NTP сервер; по умолчанию используются локальные часы")
+	fs.DurationVar(&sf.poll, "poll", 64*time.Second, "интервал синхронизации с вышестоящим сервером")
+	fs.IntVar(&sf.maxFailures, "max-failures", 3, "число неудачных синхронизаций подряд, после которого сервер сообщает о рассинхронизации; 0 — никогда")
+
+	fs.Parse(args)
+
+	switch {
+	case sf.poll <= 0:
+		return sf, fmt.Errorf("интервал синхронизации -poll должен быть больше нуля: %v", sf.poll)
+	case sf.maxFailures < 0:
+		return sf, fmt.Errorf("число неудачных синхронизаций -max-failures не может быть отрицательным: %d", sf.maxFailures)
+	}
+
+	return sf, nil
+}
+
+// serve запускает SNTP сервер
+func serve(client NTPClient, sf ServeFlags) {
+	server := NewLocalServer()
+	if sf.upstream != "" {
+		var resync func() error
+		var err error
+		server, resync, err = NewUpstreamServer(client, sf.upstream, sf.maxFailures)
+		if err != nil {
+			log.Printf("Ошибка синхронизации с вышестоящим сервером: %v", err)
+			os.Exit(exitNTPError)
+		}
+		go func() {
+			for range time.Tick(sf.poll) {
+				if err := resync(); err != nil {
+					log.Printf("Ошибка синхронизации с вышестоящим сервером: %v", err)
+				}
+			}
+		}()
+	}
+
+	conn, err := net.ListenPacket("udp", sf.listen)
+	if err != nil {
+		log.Printf("Ошибка запуска сервера: %v", err)
+		os.Exit(exitServer)
+	}
+	defer conn.Close()
+
+	log.Printf("SNTP сервер запущен на %s", conn.LocalAddr())
+	if err := server.Serve(conn); err != nil {
+		log.Printf("Ошибка работы сервера: %v", err)
+		os.Exit(exitServer)
+	}
+}
+
+// MonitorFlags содержит флаги режима мониторинга
+type MonitorFlags struct {
+	listen     string
+	host       string
+	interval   time.Duration
+	maxBackoff time.Duration
+	history    int
+}
+
+// parseMonitorFlags парсит и проверяет флаги режима мониторинга
+func parseMonitorFlags(args []string) (MonitorFlags, error) {
+	var mf MonitorFlags
+	fs := flag.NewFlagSet("monitor", flag.ExitOnError)
+
+	fs.StringVar(&mf.listen, "listen", ":9123", "HTTP адрес для отдачи метрик")
+	fs.StringVar(&mf.host, "server", defaultHost, "опрашиваемый NTP сервер")
+	fs.DurationVar(&mf.interval, "interval", time.Minute, "интервал опроса сервера")
+	fs.DurationVar(&mf.maxBackoff, "max-backoff", 30*time.Minute, "максимальный интервал опроса при ошибках")
+	fs.IntVar(&mf.history, "history", 60, "количество хранимых измерений смещения")
+
+	fs.Parse(args)
+
+	switch {
+	case mf.interval <= 0:
+		return mf, fmt.Errorf("интервал опроса -interval должен быть больше нуля: %v", mf.interval)
+	case mf.maxBackoff < mf.interval:
+		return mf, fmt.Errorf("интервал -max-backoff (%v) не может быть меньше -interval (%v)", mf.maxBackoff, mf.interval)
+	case mf.history < 1:
+		return mf, fmt.Errorf("размер истории -history должен быть не меньше 1: %d", mf.history)
+	}
+
+	return mf, nil
+}
+
+// monitor запускает периодический опрос сервера и HTTP сервер с метриками
+func monitor(client NTPClient, mf MonitorFlags) {
+	m := NewMonitor(client, mf.host, mf.interval, mf.maxBackoff, mf.history)
+	go m.Run(context.Background())
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m)
+
+	log.Printf("Метрики доступны на %s/metrics", mf.listen)
+	if err := http.ListenAndServe(mf.listen, mux); err != nil {
+		log.Printf("Ошибка запуска HTTP сервера: %v", err)
+		os.Exit(exitServer)
+	}
+}
+
+// AdjustFlags содержит флаги режима коррекции часов
+type AdjustFlags struct {
+	servers        []string
+	tolerance      time.Duration
+	minOffset      time.Duration
+	stepThreshold  time.Duration
+	panicThreshold time.Duration
+	apply          bool
+}
+
+// parseAdjustFlags парсит флаги режима коррекции часов
+func parseAdjustFlags(args []string) AdjustFlags {
+	var af AdjustFlags
+	var servers string
+	fs := flag.NewFlagSet("adjust", flag.ExitOnError)
+
+	fs.StringVar(&servers, "servers", defaultHost, "список NTP серверов через запятую")
+	fs.DurationVar(&af.tolerance, "tolerance", 100*time.Millisecond, "допустимое расхождение сервера при отборе")
+	fs.DurationVar(&af.minOffset, "min", time.Millisecond, "минимальное смещение, требующее коррекции")
+	fs.DurationVar(&af.stepThreshold, "step", 128*time.Millisecond, "смещение, начиная с которого часы переводятся скачком; смещения больше 0.5 с переводятся скачком всегда")
+	fs.DurationVar(&af.panicThreshold, "panic", 1000*time.Second, "максимальное допустимое смещение; 0 — без ограничения")
+	fs.BoolVar(&af.apply, "apply", false, "применить коррекцию (требует привилегий); иначе только напечатать ее")
+
+	fs.Parse(args)
+
+	af.servers = splitList(servers)
+
+	return af
+}
+
+// adjust вычисляет коррекцию локальных часов и при необходимости применяет ее
+func adjust(client NTPClient, clock SystemClock, af AdjustFlags) {
+	sel := selectServers(client, af.servers, af.tolerance)
+
+	a, err := planAdjustment(sel.Offset, af.minOffset, af.stepThreshold, af.panicThreshold)
+	if err != nil {
+		log.Printf("Коррекция отклонена: %v", err)
+		os.Exit(exitPanic)
+	}
+	fmt.Println(a)
+
+	if !af.apply {
+		return
+	}
+	if err := applyAdjustment(clock, a); err != nil {
+		log.Printf("Ошибка коррекции часов: %v", err)
+		os.Exit(exitClock)
+	}
 }
 
 func main() {
 	client := RealNTPClient{}
-	printCurrentTime(client)
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "serve":
+			sf, err := parseServeFlags(os.Args[2:])
+			if err != nil {
+				log.Printf("Некорректные аргументы: %v", err)
+				os.Exit(exitUsage)
+			}
+			serve(client, sf)
+			return
+		case "monitor":
+			mf, err := parseMonitorFlags(os.Args[2:])
+			if err != nil {
+				log.Printf("Некорректные аргументы: %v", err)
+				os.Exit(exitUsage)
+			}
+			monitor(client, mf)
+			return
+		case "adjust":
+			adjust(client, newSystemClock(), parseAdjustFlags(os.Args[2:]))
+			return
+		}
+	}
+
+	tf, err := parseFlags()
+	if err != nil {
+		log.Printf("Некорректные аргументы: %v", err)
+		os.Exit(exitUsage)
+	}
+
+	if tf.verbose || tf.json {
+		if tf.output.plain {
+			log.Print("Флаги -tz и -format не применяются к отчету -verbose/-json")
+		}
+		hosts := tf.servers
+		if len(hosts) == 0 {
+			hosts = []string{defaultHost}
+		}
+		printHostReports(client, hosts, tf.json)
+		return
+	}
+
+	switch len(tf.servers) {
+	case 0:
+		printCurrentTime(client, tf.output)
+	case 1:
+		printHostTime(client, tf.servers[0], tf.output)
+	default:
+		printCombinedTime(client, tf.servers, tf.tolerance, tf.output)
+	}
 }
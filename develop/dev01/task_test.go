@@ -1,8 +1,12 @@
 package main
 
 import (
+	"errors"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/beevik/ntp"
 )
 
 // MockNTPClient представляет мок для NTP клиента
@@ -13,6 +17,77 @@ func (m MockNTPClient) Time(host string) (time.Time, error) {
 	return time.Date(2023, time.March, 12, 15, 30, 0, 0, time.UTC), nil
 }
 
+// Query возвращает фиксированный ответ сервера для тестирования
+func (m MockNTPClient) Query(host string) (*ntp.Response, error) {
+	return &ntp.Response{
+		Time:           time.Date(2023, time.March, 12, 15, 30, 0, 0, time.UTC),
+		ClockOffset:    15 * time.Millisecond,
+		RTT:            40 * time.Millisecond,
+		Stratum:        2,
+		ReferenceID:    0xc0a80001,
+		RootDispersion: 2 * time.Millisecond,
+	}, nil
+}
+
+// ScriptedReply описывает ответ ScriptedNTPClient на один запрос
+type ScriptedReply struct {
+	Offset   time.Duration
+	Stratum  uint8  // 0 — стратум 2
+	KissCode string // код Kiss-o'-Death; стратум ответа при этом нулевой
+	Err      error  // ошибка опроса вместо ответа
+}
+
+// ScriptedNTPClient отвечает по сценарию: каждому серверу сопоставлена
+// очередь ответов, которые возвращаются по порядку, последний повторяется.
+// Неизвестный сервер недоступен. Клиент можно опрашивать конкурентно.
+type ScriptedNTPClient struct {
+	base    time.Time // время серверов без смещения; нулевое — текущее время
+	replies map[string][]ScriptedReply
+
+	mu sync.Mutex
+}
+
+// Time возвращает время из очередного ответа сервера
+func (c *ScriptedNTPClient) Time(host string) (time.Time, error) {
+	resp, err := c.Query(host)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return resp.Time, nil
+}
+
+// Query возвращает очередной ответ сервера по сценарию
+func (c *ScriptedNTPClient) Query(host string) (*ntp.Response, error) {
+	c.mu.Lock()
+	replies := c.replies[host]
+	if len(replies) > 1 {
+		c.replies[host] = replies[1:]
+	}
+	c.mu.Unlock()
+
+	if len(replies) == 0 {
+		return nil, errors.New("сервер недоступен")
+	}
+	r := replies[0]
+	if r.Err != nil {
+		return nil, r.Err
+	}
+
+	base := c.base
+	if base.IsZero() {
+		base = time.Now()
+	}
+	t := base.Add(r.Offset)
+	resp := &ntp.Response{Time: t, ReferenceTime: t, ClockOffset: r.Offset, RTT: 10 * time.Millisecond, Stratum: 2}
+	switch {
+	case r.KissCode != "":
+		resp.Stratum, resp.KissCode = 0, r.KissCode
+	case r.Stratum != 0:
+		resp.Stratum = r.Stratum
+	}
+	return resp, nil
+}
+
 func TestGetCurrentTime(t *testing.T) {
 	// Используем мок для тестирования
 	client := MockNTPClient{}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Коды выхода программы
+const (
+	exitOK       = 0
+	exitBadInput = 1 // некорректные входные данные
+	exitUsage    = 2 // некорректные аргументы командной строки
+	exitIO       = 3 // ошибка чтения или записи
+)
+
+// usage — описание использования программы
+const usage = `Использование: dev02 decode|encode [-lines] [-graphemes] [-max байт] [файл ...]
+Без файлов или с файлом "-" данные читаются из стандартного ввода.
+`
+
+// CLIFlags содержит флаги подкоманд decode и encode
+type CLIFlags struct {
+	lines     bool
+	maxSize   int64
+	graphemes bool
+	files     []string
+}
+
+// parseCLIFlags парсит флаги подкоманды
+func parseCLIFlags(name string, args []string, stderr io.Writer) (CLIFlags, error) {
+	var cf CLIFlags
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	fs.BoolVar(&cf.lines, "lines", false, "обрабатывать каждую строку отдельно")
+	fs.Int64Var(&cf.maxSize, "max", 0, "максимальный размер распакованных данных в байтах; 0 — без ограничения")
+	fs.BoolVar(&cf.graphemes, "graphemes", false, "повторять кластеры графем целиком, а не отдельные руны")
+
+	if err := fs.Parse(args); err != nil {
+		return cf, err
+	}
+
+	cf.files = fs.Args()
+	if len(cf.files) == 0 {
+		cf.files = []string{"-"}
+	}
+
+	return cf, nil
+}
+
+// newDecoder создает декодер с параметрами из флагов
+func (cf CLIFlags) newDecoder(r io.Reader) *Decoder {
+	d := NewDecoder(r, cf.maxSize)
+	if cf.graphemes {
+		d.UseGraphemes()
+	}
+	return d
+}
+
+// pack упаковывает строку по рунам или по кластерам графем в зависимости от флагов
+func (cf CLIFlags) pack(s string) (string, error) {
+	if cf.graphemes {
+		return PackGraphemes(s)
+	}
+	return Pack(s)
+}
+
+// locationError содержит место ошибки во входных данных
+type locationError struct {
+	file string
+	line int64 // номер строки с единицы в режиме -lines; 0 — поток целиком
+	err  error
+}
+
+// Error возвращает описание ошибки в формате файл:строка:столбец. При
+// обработке потока целиком строка берется из позиции ошибки.
+func (e *locationError) Error() string {
+	var syntaxErr *SyntaxError
+	if !errors.As(e.err, &syntaxErr) {
+		return fmt.Sprintf("%s: %v", e.file, e.err)
+	}
+	line := syntaxErr.Line
+	if e.line > 0 {
+		line = e.line
+	}
+	return fmt.Sprintf("%s:%d:%d: %v", e.file, line, syntaxErr.Column, syntaxErr.Err)
+}
+
+// Unwrap возвращает исходную ошибку
+func (e *locationError) Unwrap() error {
+	return e.err
+}
+
+// run выполняет подкоманду и возвращает код выхода
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 || (args[0] != "decode" && args[0] != "encode") {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+
+	cf, err := parseCLIFlags(args[0], args[1:], stderr)
+	if err != nil {
+		return exitUsage
+	}
+
+	w := bufio.NewWriter(stdout)
+	for _, name := range cf.files {
+		err = processFile(args[0], name, cf, stdin, w)
+		if err != nil {
+			break
+		}
+	}
+	if ferr := w.Flush(); err == nil {
+		err = ferr
+	}
+
+	var syntaxErr *SyntaxError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &syntaxErr):
+		fmt.Fprintf(stderr, "dev02: %v\n", err)
+		return exitBadInput
+	default:
+		fmt.Fprintf(stderr, "dev02: %v\n", err)
+		return exitIO
+	}
+}
+
+// processFile распаковывает или упаковывает один файл
+func processFile(cmd, name string, cf CLIFlags, stdin io.Reader, w io.Writer) error {
+	r := stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var err error
+	switch {
+	case cf.lines:
+		err = processLines(cmd, name, r, w, cf)
+	case cmd == "decode":
+		_, err = cf.newDecoder(r).Decode(w)
+	default:
+		err = encodeStream(r, w, cf)
+	}
+
+	var locErr *locationError
+	if err != nil && !errors.As(err, &locErr) {
+		err = &locationError{file: name, err: err}
+	}
+	return err
+}
+
+// processLines обрабатывает каждую строку отдельно, сохраняя переводы строк.
+// Ограничение размера при распаковке применяется к каждой строке.
+func processLines(cmd, name string, r io.Reader, w io.Writer, cf CLIFlags) error {
+	br := bufio.NewReader(r)
+	for line := int64(1); ; line++ {
+		text, err := br.ReadString('\n')
+		if text == "" && err == io.EOF {
+			return nil
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		newline := strings.HasSuffix(text, "\n")
+		text = strings.TrimSuffix(text, "\n")
+
+		var out string
+		if cmd == "decode" {
+			var b strings.Builder
+			_, err = cf.newDecoder(strings.NewReader(text)).Decode(&b)
+			out = b.String()
+		} else {
+			out, err = cf.pack(text)
+		}
+		if err != nil {
+			return &locationError{file: name, line: line, err: err}
+		}
+
+		if newline {
+			out += "\n"
+		}
+		if _, err := io.WriteString(w, out); err != nil {
+			return err
+		}
+	}
+}
+
+// encodeStream упаковывает поток целиком
+func encodeStream(r io.Reader, w io.Writer, cf CLIFlags) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	packed, err := cf.pack(string(data))
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, packed)
+	return err
+}
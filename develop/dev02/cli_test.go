@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "packed.txt")
+	if err := os.WriteFile(file, []byte("a3\nb\\42\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		args   []string
+		stdin  string
+		stdout string
+		stderr string
+		code   int
+	}{
+		{"decode stream", []string{"decode"}, "a4bc2\\5\n3", "aaaabcc5\n\n\n", "", exitOK},
+		{"decode lines", []string{"decode", "-lines"}, "a3\nb2\n", "aaa\nbb\n", "", exitOK},
+		{"decode file", []string{"decode", "-lines", file}, "", "aaa\nb44\n", "", exitOK},
+		{"decode stdin and file", []string{"decode", "-", file}, "x2", "xxaaa\nb44\n", "", exitOK},
+		{"encode stream", []string{"encode"}, "aaaa\n\n\n", "a4\n3", "", exitOK},
+		{"decode graphemes", []string{"decode", "-graphemes"}, "👍🏽3", "👍🏽👍🏽👍🏽", "", exitOK},
+		{"encode graphemes", []string{"encode", "-graphemes"}, "е\u0301е\u0301е\u0301", "е\u03013", "", exitOK},
+		{"encode lines", []string{"encode", "-lines"}, "aaaa\n55\n", "a4\n\\52\n", "", exitOK},
+		{"malformed stream", []string{"decode"}, "ab\nc0", "ab\n", "dev02: -:2:2: нулевое число повторов\n", exitBadInput},
+		{"malformed stream escape", []string{"decode"}, "a\nжж\n\\x", "a\nжж\n", "dev02: -:3:1: экранировать можно только цифры и обратную косую черту\n", exitBadInput},
+		{"malformed line", []string{"decode", "-lines"}, "ok\nжж\\", "ok\n", "dev02: -:2:3: незавершенное экранирование\n", exitBadInput},
+		{"too large", []string{"decode", "-max", "3"}, "a4", "", "dev02: -:1:1: превышен максимальный размер распакованных данных\n", exitBadInput},
+		{"missing file", []string{"decode", filepath.Join(dir, "missing")}, "", "", "", exitIO},
+		{"unknown command", []string{"zip"}, "", "", usage, exitUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+			if code != tt.code {
+				t.Errorf("run(%v) = %d; want %d (stderr: %s)", tt.args, code, tt.code, stderr.String())
+			}
+			if stdout.String() != tt.stdout {
+				t.Errorf("run(%v) stdout = %q; want %q", tt.args, stdout.String(), tt.stdout)
+			}
+			if tt.stderr != "" && stderr.String() != tt.stderr {
+				t.Errorf("run(%v) stderr = %q; want %q", tt.args, stderr.String(), tt.stderr)
+			}
+		})
+	}
+}
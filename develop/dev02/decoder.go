@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"unicode/utf8"
+)
+
+// Ошибки разбора входных данных. Возвращаются обернутыми в SyntaxError,
+// содержащую позицию ошибки.
+var (
+	ErrLeadingDigit   = errors.New("число повторов без символа")
+	ErrDanglingEscape = errors.New("незавершенное экранирование")
+	ErrInvalidEscape  = errors.New("экранировать можно только цифры и обратную косую черту")
+	ErrZeroCount      = errors.New("нулевое число повторов")
+	ErrCountOverflow  = errors.New("слишком большое число повторов")
+	ErrInvalidUTF8    = errors.New("некорректная последовательность UTF-8")
+	ErrTooLarge       = errors.New("превышен максимальный размер распакованных данных")
+)
+
+// Position описывает позицию руны во входных данных
+type Position struct {
+	Offset int64 // номер руны с нуля
+	Line   int64 // номер строки с единицы
+	Column int64 // номер руны в строке с единицы
+}
+
+// startPosition — позиция первой руны входных данных
+var startPosition = Position{Line: 1, Column: 1}
+
+// advance возвращает позицию руны, следующей за char
+func (p Position) advance(char rune) Position {
+	p.Offset++
+	if char == '\n' {
+		p.Line++
+		p.Column = 1
+	} else {
+		p.Column++
+	}
+	return p
+}
+
+// SyntaxError описывает ошибку во входных данных и позицию руны, на
+// которой она обнаружена
+type SyntaxError struct {
+	Position
+	Err error
+}
+
+// Error возвращает описание ошибки с позицией
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("позиция %d: %v", e.Offset, e.Err)
+}
+
+// Unwrap возвращает причину ошибки
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// Decoder выполняет потоковую распаковку данных из io.Reader.
+//
+// Грамматика входных данных:
+//
+//	data   = { item }
+//	item   = symbol [ count ]
+//	symbol = любой символ, кроме цифры и '\' | '\' цифра | '\' '\'
+//	count  = десятичное число без ведущих нулей, больше нуля
+//
+// Например, "a12" — двенадцать символов a, "\\45" — пять цифр 4.
+type Decoder struct {
+	r         *bufio.Reader
+	maxSize   int64
+	pos       Position
+	graphemes bool
+}
+
+// NewDecoder создает декодер, читающий из r. Если maxSize больше нуля,
+// распаковка прерывается с ошибкой ErrTooLarge при превышении maxSize байт.
+func NewDecoder(r io.Reader, maxSize int64) *Decoder {
+	return &Decoder{r: bufio.NewReader(r), maxSize: maxSize, pos: startPosition}
+}
+
+// UseGraphemes включает режим, в котором повторяется не последняя руна, а
+// последний расширенный кластер графем (UAX #29): буква с диакритическими
+// знаками, эмодзи с модификаторами и последовательности с ZWJ повторяются целиком.
+// Границы кластеров определяются по правилам UAX #29 той же функцией, что и
+// в PackGraphemes; экранированные цифры и '\' продолжают кластер так же,
+// как неэкранированные руны.
+func (d *Decoder) UseGraphemes() {
+	d.graphemes = true
+}
+
+// minSymbolCheck — размер в байтах накопленных символов, начиная с которого
+// декодер ищет в них границы и записывает завершенные символы
+const minSymbolCheck = 64
+
+// Decode распаковывает все входные данные в w и возвращает число записанных байт
+func (d *Decoder) Decode(w io.Writer) (written int64, err error) {
+	bw := bufio.NewWriter(w)
+	defer func() {
+		if ferr := bw.Flush(); err == nil {
+			err = ferr
+		}
+	}()
+
+	var (
+		text     []byte           // прочитанные символы (руны или кластеры графем), ожидающие записи
+		textPos  []Position       // позиции рун text
+		checked  = minSymbolCheck // длина text, при которой снова ищутся границы символов
+		count    int64            // накопленное число повторов
+		countPos Position         // позиция первой цифры числа
+		inCount  bool
+	)
+
+	// repeat записывает символ, начинающийся в позиции pos, n раз
+	repeat := func(symbol []byte, pos Position, n int64) error {
+		size := n * int64(len(symbol))
+		if size/n != int64(len(symbol)) {
+			return &SyntaxError{Position: countPos, Err: ErrCountOverflow}
+		}
+		if d.maxSize > 0 && size > d.maxSize-written {
+			return &SyntaxError{Position: pos, Err: ErrTooLarge}
+		}
+		if err := writeRepeated(bw, symbol, n); err != nil {
+			return err
+		}
+		written += size
+		return nil
+	}
+
+	// flush записывает символы text по одному разу, а последний — count раз,
+	// если за ним следует число. При keepLast последний символ остается в
+	// text: к нему еще может относиться число, а в режиме графем он может
+	// продолжиться следующими рунами.
+	flush := func(keepLast bool) error {
+		start, runes := 0, 0
+		for start < len(text) {
+			size := d.symbolSize(text[start:])
+			last := start+size == len(text)
+			if last && keepLast {
+				break
+			}
+			n := int64(1)
+			if last && inCount {
+				n = count
+			}
+			symbol := text[start : start+size]
+			if err := repeat(symbol, textPos[runes], n); err != nil {
+				return err
+			}
+			start += size
+			runes += utf8.RuneCount(symbol)
+		}
+		text = text[:copy(text, text[start:])]
+		textPos = textPos[:copy(textPos, textPos[runes:])]
+		checked = max(2*len(text), minSymbolCheck)
+		return nil
+	}
+
+	// fail записывает все символы, к которым не относится ошибка err, и
+	// возвращает err; при keepLast ошибка относится к последнему символу
+	fail := func(err error, keepLast bool) (int64, error) {
+		if ferr := flush(keepLast); ferr != nil {
+			return written, ferr
+		}
+		return written, err
+	}
+
+	for {
+		char, pos, err := d.readRune()
+		if err == io.EOF {
+			return written, flush(false)
+		}
+		if err != nil {
+			return fail(err, true)
+		}
+
+		if isCountDigit(char) {
+			if len(text) == 0 {
+				return written, &SyntaxError{Position: pos, Err: ErrLeadingDigit}
+			}
+			if !inCount {
+				// Число не может начинаться с нуля
+				if char == '0' {
+					return fail(&SyntaxError{Position: pos, Err: ErrZeroCount}, true)
+				}
+				// Символы перед повторяемым записываются сразу
+				if err := flush(true); err != nil {
+					return written, err
+				}
+				inCount, countPos = true, pos
+			}
+			digit := int64(char - '0')
+			if count > (math.MaxInt64-digit)/10 {
+				return fail(&SyntaxError{Position: countPos, Err: ErrCountOverflow}, true)
+			}
+			count = count*10 + digit
+			continue
+		}
+
+		if char == '\\' {
+			escPos := pos
+			char, pos, err = d.readRune()
+			switch {
+			case err == io.EOF:
+				return fail(&SyntaxError{Position: escPos, Err: ErrDanglingEscape}, false)
+			case err != nil:
+				return fail(err, true)
+			case !isCountDigit(char) && char != '\\':
+				return fail(&SyntaxError{Position: escPos, Err: ErrInvalidEscape}, false)
+			}
+		}
+
+		// После числа символ завершен; иначе руна, в том числе
+		// экранированная, может продолжать кластер графем
+		if inCount {
+			if err := flush(false); err != nil {
+				return written, err
+			}
+			inCount, count = false, 0
+		}
+		text = utf8.AppendRune(text, char)
+		textPos = append(textPos, pos)
+		if len(text) >= checked {
+			if err := flush(true); err != nil {
+				return written, err
+			}
+		}
+	}
+}
+
+// symbolSize возвращает длину в байтах первого символа data: руны или, в
+// режиме графем, кластера графем
+func (d *Decoder) symbolSize(data []byte) int {
+	if d.graphemes {
+		return graphemeSize(data)
+	}
+	_, size := utf8.DecodeRune(data)
+	return size
+}
+
+// readRune читает очередную руну и возвращает ее позицию
+func (d *Decoder) readRune() (rune, Position, error) {
+	char, size, err := d.r.ReadRune()
+	if err != nil {
+		return 0, d.pos, err
+	}
+	pos := d.pos
+	d.pos = d.pos.advance(char)
+	if char == utf8.RuneError && size == 1 {
+		return 0, pos, &SyntaxError{Position: pos, Err: ErrInvalidUTF8}
+	}
+	return char, pos, nil
+}
+
+// isCountDigit проверяет, является ли руна цифрой числа повторов
+func isCountDigit(char rune) bool {
+	return char >= '0' && char <= '9'
+}
+
+// writeRepeated записывает символ n раз
+func writeRepeated(w io.Writer, symbol []byte, n int64) error {
+	var buf [4096]byte
+	chunk := buf[:0]
+	for i := int64(0); i < n && len(chunk)+len(symbol) <= len(buf); i++ {
+		chunk = append(chunk, symbol...)
+	}
+	if len(chunk) == 0 {
+		chunk = symbol
+	}
+	per := int64(len(chunk) / len(symbol))
+
+	for ; n > 0; n -= per {
+		if n < per {
+			chunk = chunk[:n*int64(len(symbol))]
+		}
+		if _, err := w.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
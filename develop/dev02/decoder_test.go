@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDecoder(t *testing.T) {
+	data := map[string]string{
+		"a12":      strings.Repeat("a", 12),
+		"a4bc2d5e": "aaaabccddddde",
+		"ж10ё":     strings.Repeat("ж", 10) + "ё",
+		"\\310":    strings.Repeat("3", 10),
+		"x1000":    strings.Repeat("x", 1000),
+		"🙂5000":    strings.Repeat("🙂", 5000),
+		"":         "",
+	}
+
+	for s, e := range data {
+		var buf bytes.Buffer
+		n, err := NewDecoder(strings.NewReader(s), 0).Decode(&buf)
+		if err != nil {
+			t.Fatalf("bad decode for %s: got error %v", s, err)
+		}
+		if buf.String() != e {
+			t.Fatalf("bad decode for %s: got %d bytes expected %d", s, buf.Len(), len(e))
+		}
+		if n != int64(len(e)) {
+			t.Fatalf("bad decode for %s: reported %d bytes expected %d", s, n, len(e))
+		}
+	}
+}
+
+func TestDecoderMaxSize(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := NewDecoder(strings.NewReader("ab9"), 10).Decode(&buf); err != nil {
+		t.Fatalf("bad decode within limit: got error %v", err)
+	}
+
+	buf.Reset()
+	_, err := NewDecoder(strings.NewReader("abc999999999999"), 1<<20).Decode(&buf)
+	if !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("bad decode for decompression bomb: expected ErrTooLarge, got %v", err)
+	}
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) || syntaxErr.Offset != 2 {
+		t.Fatalf("bad decode for decompression bomb: expected offset 2, got %v", err)
+	}
+	if buf.String() != "ab" {
+		t.Fatalf("bad decode for decompression bomb: expected partial output %q, got %q", "ab", buf.String())
+	}
+}
+
+// cappedWriter принимает не больше limit байт, чтобы тест не писал гигабайты при ошибке
+type cappedWriter struct {
+	bytes.Buffer
+	limit int
+}
+
+func (w *cappedWriter) Write(p []byte) (int, error) {
+	if w.Len()+len(p) > w.limit {
+		return 0, errors.New("writer limit exceeded")
+	}
+	return w.Buffer.Write(p)
+}
+
+func TestDecoderMaxSizeOverflow(t *testing.T) {
+	// written+size переполняет int64 и не должен обходить ограничение
+	w := &cappedWriter{limit: 1 << 20}
+	_, err := NewDecoder(strings.NewReader("ba9223372036854775807"), 100).Decode(w)
+	if !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("bad decode for overflowing count: expected ErrTooLarge, got %v", err)
+	}
+	if w.String() != "b" {
+		t.Fatalf("bad decode for overflowing count: expected partial output %q, got %d bytes", "b", w.Len())
+	}
+}
+
+func TestDecoderErrorOffset(t *testing.T) {
+	data := map[string]int64{
+		"45":                    0,
+		"жж\\":                  2,
+		"ab0":                   2,
+		"a99999999999999999999": 1,
+		"ab\xffc":               2,
+	}
+
+	for s, offset := range data {
+		_, err := NewDecoder(strings.NewReader(s), 0).Decode(&bytes.Buffer{})
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Fatalf("bad decode for %q: expected SyntaxError, got %v", s, err)
+		}
+		if syntaxErr.Offset != offset {
+			t.Fatalf("bad decode for %q: expected offset %d, got %d", s, offset, syntaxErr.Offset)
+		}
+	}
+}
+
+func TestDecoderErrorPosition(t *testing.T) {
+	data := map[string]Position{
+		"ab\nc0":   {Offset: 4, Line: 2, Column: 2},
+		"\n\nж\\x": {Offset: 3, Line: 3, Column: 2},
+		"a2\n0":    {Offset: 3, Line: 2, Column: 1},
+		"x\n\xff":  {Offset: 2, Line: 2, Column: 1},
+	}
+
+	for s, pos := range data {
+		_, err := NewDecoder(strings.NewReader(s), 0).Decode(&bytes.Buffer{})
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Fatalf("bad decode for %q: expected SyntaxError, got %v", s, err)
+		}
+		if syntaxErr.Position != pos {
+			t.Fatalf("bad decode for %q: expected position %+v, got %+v", s, pos, syntaxErr.Position)
+		}
+	}
+}
+
+func TestDecoderLongCluster(t *testing.T) {
+	// Границы кластера ищутся не после каждой руны, поэтому длинный кластер
+	// распаковывается за линейное время
+	cluster := "a" + strings.Repeat("\u0301", 200000)
+	d := NewDecoder(strings.NewReader(cluster+"2b"), 0)
+	d.UseGraphemes()
+	var buf bytes.Buffer
+	if _, err := d.Decode(&buf); err != nil {
+		t.Fatalf("bad decode for long cluster: got error %v", err)
+	}
+	if buf.String() != cluster+cluster+"b" {
+		t.Fatalf("bad decode for long cluster: got %d bytes expected %d", buf.Len(), 2*len(cluster)+1)
+	}
+}
@@ -1,3 +1,5 @@
 module dev02
 
 go 1.22.2
+
+require github.com/clipperhouse/uax29/v2 v2.7.0
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strings"
+
+	uax29 "github.com/clipperhouse/uax29/v2/graphemes"
+)
+
+// graphemeSize возвращает длину в байтах первого расширенного кластера
+// графем в data по правилам UAX #29 (Unicode 17, включая GB9c для
+// индийских письменностей)
+func graphemeSize(data []byte) int {
+	size, _, _ := uax29.SplitFunc(data, true)
+	return size
+}
+
+// graphemes разбивает строку на расширенные кластеры графем
+func graphemes(s string) []string {
+	var clusters []string
+	data := []byte(s)
+	for start := 0; start < len(data); {
+		size := graphemeSize(data[start:])
+		clusters = append(clusters, s[start:start+size])
+		start += size
+	}
+	return clusters
+}
+
+// UnpackGraphemes выполняет распаковку строки, повторяя кластеры графем целиком:
+// "é3" с комбинируемым ударением дает три буквы é, а не e с тремя ударениями.
+func UnpackGraphemes(s string) (string, error) {
+	var b strings.Builder
+	d := NewDecoder(strings.NewReader(s), 0)
+	d.UseGraphemes()
+	if _, err := d.Decode(&b); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// PackGraphemes выполняет упаковку, обратную UnpackGraphemes: сериями
+// считаются подряд идущие одинаковые кластеры графем.
+func PackGraphemes(s string) (string, error) {
+	if err := checkUTF8(s); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	clusters := graphemes(s)
+	for i := 0; i < len(clusters); {
+		j := i
+		for j < len(clusters) && clusters[j] == clusters[i] {
+			j++
+		}
+		writeRun(&b, escapeCluster(clusters[i]), j-i)
+		i = j
+	}
+	return b.String(), nil
+}
+
+// escapeCluster экранирует цифры и обратную косую черту в кластере
+func escapeCluster(cluster string) string {
+	var b strings.Builder
+	for _, r := range cluster {
+		b.WriteString(escape(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+)
+
+func TestGraphemes(t *testing.T) {
+	data := map[string][]string{
+		"abc":                      {"a", "b", "c"},
+		"е\u0301ж":                 {"е\u0301", "ж"},
+		"и\u0306к":                 {"и\u0306", "к"},
+		"\r\n\n":                   {"\r\n", "\n"},
+		"👍🏽👍":                      {"👍🏽", "👍"},
+		"👨\u200d👩\u200d👧x":         {"👨\u200d👩\u200d👧", "x"},
+		"🇷🇺🇷🇺🇷":                    {"🇷🇺", "🇷🇺", "🇷"},
+		"\u1100\u1161\u11a8\uac00": {"\u1100\u1161\u11a8", "\uac00"},
+		"a\u200db":                 {"a\u200d", "b"},
+		"4\ufe0f\u20e3":            {"4\ufe0f\u20e3"},
+		"\u0600\u0661":             {"\u0600\u0661"},
+		"क्षत्रिय":                 {"क्ष", "त्रि", "य"},
+		"ক্ষমা":                    {"ক্ষ", "মা"},
+		"กำกำ":                     {"กำ", "กำ"},
+		"น้ำ":                      {"น้ำ"},
+	}
+
+	for s, e := range data {
+		if got := graphemes(s); !reflect.DeepEqual(got, e) {
+			t.Errorf("graphemes(%q) = %q; want %q", s, got, e)
+		}
+	}
+}
+
+func TestUnpackGraphemes(t *testing.T) {
+	data := map[string]string{
+		"е\u03013":         strings.Repeat("е\u0301", 3),
+		"и\u03062к":        "и\u0306и\u0306к",
+		"ёжик2":            "ёжикк",
+		"👍🏽3":              "👍🏽👍🏽👍🏽",
+		"👨\u200d👩\u200d👧2": "👨\u200d👩\u200d👧👨\u200d👩\u200d👧",
+		"🇷🇺3":              "🇷🇺🇷🇺🇷🇺",
+		"\\4\u20e32":       "4\u20e34\u20e3",
+		"a4bc2d5e":         "aaaabccddddde",
+		"क्ष3":             "क्षक्षक्ष",
+		"त्रि2य":           "त्रित्रिय",
+		"กำ3":              "กำกำกำ",
+	}
+
+	for s, e := range data {
+		r, err := UnpackGraphemes(s)
+		if err != nil {
+			t.Fatalf("bad unpack for %q: got error %v", s, err)
+		}
+		if r != e {
+			t.Fatalf("bad unpack for %q: got %q expected %q", s, r, e)
+		}
+	}
+
+	// В обычном режиме повторяется только последняя руна
+	if r, _ := Unpack("е\u03013"); r != "е\u0301\u0301\u0301" {
+		t.Fatalf("bad unpack for combining mark in rune mode: got %q", r)
+	}
+}
+
+func TestPackGraphemes(t *testing.T) {
+	data := map[string]string{
+		"е\u0301е\u0301е\u0301": "е\u03013",
+		"👍🏽👍🏽":                  "👍🏽2",
+		"4\u20e34\u20e3":        "\\4\u20e32",
+		"aaaabccddddde":         "a4bccd5e",
+		"\u06005\u06005":        "\u0600\\52",
+		"क्षक्षक्ष":             "क्ष3",
+		"กำกำกำ":                "กำ3",
+	}
+
+	for s, e := range data {
+		r, err := PackGraphemes(s)
+		if err != nil {
+			t.Fatalf("bad pack for %q: got error %v", s, err)
+		}
+		if r != e {
+			t.Fatalf("bad pack for %q: got %q expected %q", s, r, e)
+		}
+	}
+}
+
+func TestPackGraphemesRoundTrip(t *testing.T) {
+	roundTrip := func(s runString) bool {
+		p, err := PackGraphemes(string(s))
+		if err != nil {
+			return false
+		}
+		r, err := UnpackGraphemes(p)
+		return err == nil && r == string(s)
+	}
+
+	// Prepend и следующая за ним цифра, индийские конъюнкты и тайские
+	// гласные образуют один кластер и при упаковке, и при распаковке
+	for _, s := range []string{"\u06005\u06005", "\u0600\\\u0600\\x", "\u06005\u06006", "\u060055",
+		"क्षक्ष5", "कक्\u200dष", "กำกำ1กำ", "🇷🇺🇷🇺🇷2"} {
+		if !roundTrip(runString(s)) {
+			t.Errorf("bad round trip for %q", s)
+		}
+	}
+
+	if err := quick.Check(roundTrip, nil); err != nil {
+		t.Error(err)
+	}
+}
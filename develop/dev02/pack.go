@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Pack выполняет упаковку строки, обратную Unpack: серии одинаковых символов
+// заменяются символом и числом повторов, цифры и обратная косая черта
+// экранируются. Выбирается самая короткая запись каждой серии.
+func Pack(s string) (string, error) {
+	if err := checkUTF8(s); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		j := i
+		for j < len(runes) && runes[j] == runes[i] {
+			j++
+		}
+		writeRun(&b, escape(runes[i]), j-i)
+		i = j
+	}
+	return b.String(), nil
+}
+
+// checkUTF8 проверяет, что строка в кодировке UTF-8, и сообщает позицию первой некорректной руны
+func checkUTF8(s string) error {
+	pos := startPosition
+	for i, char := range s {
+		if _, size := utf8.DecodeRuneInString(s[i:]); char == utf8.RuneError && size == 1 {
+			return &SyntaxError{Position: pos, Err: ErrInvalidUTF8}
+		}
+		pos = pos.advance(char)
+	}
+	return nil
+}
+
+// writeRun записывает серию из n одинаковых экранированных символов e
+func writeRun(b *strings.Builder, e string, n int) {
+	count := strconv.Itoa(n)
+
+	// Повтор символа короче записи с числом, если символ не экранирован и
+	// встречается не более двух раз
+	if n == 1 || len(e)*n <= len(e)+len(count) {
+		b.WriteString(strings.Repeat(e, n))
+	} else {
+		b.WriteString(e)
+		b.WriteString(count)
+	}
+}
+
+// escape экранирует цифры и обратную косую черту
+func escape(char rune) string {
+	if isCountDigit(char) || char == '\\' {
+		return `\` + string(char)
+	}
+	return string(char)
+}
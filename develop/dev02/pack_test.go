@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+	"unicode/utf8"
+)
+
+func TestPack(t *testing.T) {
+	data := map[string]string{
+		"aaaabccddddde": "a4bccd5e",
+		"abcd":          "abcd",
+		"":              "",
+		"qwe45":         "qwe\\4\\5",
+		"qwe44444":      "qwe\\45",
+		"qwe\\\\\\\\\\": "qwe\\\\5",
+		"aaaaaaaaaa":    "a10",
+		"aaaaaaaaaaaa":  "a12",
+		"ппп":           "п3",
+		"77":            "\\72",
+		"a\\b":          "a\\\\b",
+		"🙂🙂🙂🙂🙂🙂🙂🙂🙂🙂🙂": "🙂11",
+	}
+
+	for s, e := range data {
+		r, err := Pack(s)
+		if err != nil {
+			t.Fatalf("bad pack for %s: got error %v", s, err)
+		}
+		if r != e {
+			t.Fatalf("bad pack for %s: got %v expected %v", s, r, e)
+		}
+	}
+}
+
+func TestPackInvalidUTF8(t *testing.T) {
+	_, err := Pack("ж\xffb")
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) || syntaxErr.Offset != 1 || !errors.Is(err, ErrInvalidUTF8) {
+		t.Fatalf("bad pack for invalid UTF-8: expected ErrInvalidUTF8 at offset 1, got %v", err)
+	}
+}
+
+// runString — строка из серий символов, в которых часто встречаются
+// цифры и обратная косая черта
+type runString string
+
+// Generate реализует quick.Generator
+func (runString) Generate(rnd *rand.Rand, size int) reflect.Value {
+	alphabet := []rune("ab0159\\жЁ🙂é́")
+	var b strings.Builder
+	for i := rnd.Intn(size + 1); i > 0; i-- {
+		var char rune
+		if rnd.Intn(4) == 0 {
+			char = rune(rnd.Intn(0x10ffff))
+			if !utf8.ValidRune(char) {
+				char = 'x'
+			}
+		} else {
+			char = alphabet[rnd.Intn(len(alphabet))]
+		}
+		b.WriteString(strings.Repeat(string(char), 1+rnd.Intn(25)))
+	}
+	return reflect.ValueOf(runString(b.String()))
+}
+
+func TestPackRoundTrip(t *testing.T) {
+	roundTrip := func(s string) bool {
+		p, err := Pack(s)
+		if err != nil {
+			return false
+		}
+		r, err := Unpack(p)
+		return err == nil && r == s
+	}
+
+	if err := quick.Check(roundTrip, nil); err != nil {
+		t.Error(err)
+	}
+	if err := quick.Check(func(s runString) bool { return roundTrip(string(s)) }, nil); err != nil {
+		t.Error(err)
+	}
+}
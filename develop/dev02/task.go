@@ -19,34 +19,20 @@ package main
 */
 
 import (
-	"errors"
-	"strconv"
+	"os"
 	"strings"
-	"unicode"
 )
 
 // Unpack выполняет распаковку строки, содержащую повторяющиеся символы.
-func Unpack(s string) (r string, err error) {
-	if _, err := strconv.Atoi(s); err == nil {
-		return r, errors.New("некорректная строка")
-	}
-
-	var prev rune
-	var escaped bool
+// Число повторов может состоять из нескольких цифр.
+func Unpack(s string) (string, error) {
 	var b strings.Builder
-	for _, char := range s {
-		if unicode.IsDigit(char) && !escaped {
-			m := int(char - '0')
-			r := strings.Repeat(string(prev), m-1)
-			b.WriteString(r)
-		} else {
-			escaped = string(char) == "\\" && string(prev) != "\\"
-			if !escaped {
-				b.WriteRune(char)
-			}
-			prev = char
-		}
+	if _, err := NewDecoder(strings.NewReader(s), 0).Decode(&b); err != nil {
+		return "", err
 	}
+	return b.String(), nil
+}
 
-	return b.String(), err
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
 }
@@ -1,6 +1,10 @@
 package main
 
-import "testing"
+import (
+	"errors"
+	"strings"
+	"testing"
+)
 
 func TestUnpack(t *testing.T) {
 	data := map[string]string{
@@ -47,3 +51,44 @@ func TestUnpackEscape(t *testing.T) {
 		}
 	}
 }
+
+func TestUnpackSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		input  string
+		err    error
+		offset int64
+	}{
+		{"45", ErrLeadingDigit, 0},
+		{"3abc", ErrLeadingDigit, 0},
+		{"abc\\", ErrDanglingEscape, 3},
+		{"a\\b", ErrInvalidEscape, 1},
+		{"a0", ErrZeroCount, 1},
+		{"ab05", ErrZeroCount, 2},
+		{"x99999999999999999999", ErrCountOverflow, 1},
+		{"пр\xff", ErrInvalidUTF8, 2},
+	}
+
+	for _, tt := range tests {
+		r, err := Unpack(tt.input)
+		if r != "" {
+			t.Fatalf("bad unpack for %q: expected empty string, got %q", tt.input, r)
+		}
+		if !errors.Is(err, tt.err) {
+			t.Fatalf("bad unpack for %q: expected error %v, got %v", tt.input, tt.err, err)
+		}
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) || syntaxErr.Offset != tt.offset {
+			t.Fatalf("bad unpack for %q: expected offset %d, got %v", tt.input, tt.offset, err)
+		}
+	}
+}
+
+func TestUnpackMultiDigit(t *testing.T) {
+	r, err := Unpack("a4b45")
+	if err != nil {
+		t.Fatalf("bad unpack for a4b45: got error %v", err)
+	}
+	if e := "aaaa" + strings.Repeat("b", 45); r != e {
+		t.Fatalf("bad unpack for a4b45: got %v expected %v", r, e)
+	}
+}
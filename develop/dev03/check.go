@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// disorderError сообщает о первой строке, нарушающей порядок при проверке
+type disorderError struct {
+	name string // имя файла, "-" для стандартного ввода
+	line int    // номер строки, с единицы
+	text string
+}
+
+// Error возвращает сообщение в формате GNU sort: "-:42: disorder: текст"
+func (e *disorderError) Error() string {
+	return fmt.Sprintf("%s:%d: disorder: %s", e.name, e.line, e.text)
+}
+
+// checkInput проверяет, что строки из r отсортированы с флагами sf, и
+// возвращает *disorderError для первой строки, нарушающей порядок. Равные
+// соседние строки порядок не нарушают; с -u ключи должны строго возрастать.
+func checkInput(r io.Reader, name string, sf SortFlags) error {
+	scanner := newRecordScanner(r, sf)
+	var prev string
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if n > 1 {
+			c := compareLines(prev, line, sf)
+			if c > 0 || (c == 0 && sf.unique) {
+				return &disorderError{name: name, line: n, text: line}
+			}
+		}
+		prev = line
+	}
+	return scanner.Err()
+}
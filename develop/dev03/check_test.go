@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Тестируем проверку порядка строк
+func TestCheckInput(t *testing.T) {
+	var keys keyList
+	keys.Set("2,2n")
+
+	tests := []struct {
+		name  string
+		input string
+		sf    SortFlags
+		line  int // номер строки с нарушением порядка; 0 — порядок не нарушен
+		text  string
+	}{
+		{"пустой ввод", "", SortFlags{}, 0, ""},
+		{"отсортировано", "a\nb\nb\nc\n", SortFlags{}, 0, ""},
+		{"нарушение", "a\nc\nb\nd\na\n", SortFlags{}, 3, "b"},
+		{"-u повтор", "a\nb\nb\nc\n", SortFlags{unique: true}, 3, "b"},
+		{"-r", "c\nb\na\n", SortFlags{reverse: true}, 0, ""},
+		{"-n", "2\n10\n9\n", SortFlags{numeric: true}, 3, "9"},
+		{"ключ и разделитель", "x:1\na:2\nb:10\n", SortFlags{keys: keys, separator: ':'}, 0, ""},
+		{"ключ с нарушением", "x:1\na:10\nb:2\n", SortFlags{keys: keys, separator: ':'}, 3, "b:2"},
+		{"-u по ключу", "x:1\na:1\n", SortFlags{keys: keys, separator: ':', unique: true}, 2, "a:1"},
+		{"-s равные ключи", "x:1\na:1\n", SortFlags{keys: keys, separator: ':', stable: true}, 0, ""},
+		{"без -s равные ключи", "x:1\na:1\n", SortFlags{keys: keys, separator: ':'}, 2, "a:1"},
+	}
+
+	for _, tt := range tests {
+		err := checkInput(strings.NewReader(tt.input), "-", tt.sf)
+		if tt.line == 0 {
+			if err != nil {
+				t.Errorf("%s: checkInput() вернула ошибку: %v", tt.name, err)
+			}
+			continue
+		}
+
+		var disorder *disorderError
+		if !errors.As(err, &disorder) {
+			t.Errorf("%s: checkInput() = %v; want disorderError", tt.name, err)
+			continue
+		}
+		if disorder.line != tt.line || disorder.text != tt.text {
+			t.Errorf("%s: нарушение в строке %d %q; want %d %q", tt.name, disorder.line, disorder.text, tt.line, tt.text)
+		}
+	}
+}
+
+// Тестируем сообщение о нарушении порядка
+func TestDisorderError(t *testing.T) {
+	err := checkInput(strings.NewReader("b\na\n"), "data.txt", SortFlags{})
+	if err == nil || err.Error() != "data.txt:2: disorder: a" {
+		t.Errorf("checkInput() = %v; want %q", err, "data.txt:2: disorder: a")
+	}
+
+	names := writeFiles(t, "a\n", "b\n")
+	if err := run(SortFlags{check: true}, names, nil); err == nil || errors.As(err, new(*disorderError)) {
+		t.Errorf("run() с -c и двумя файлами = %v; want ошибку о лишнем операнде", err)
+	}
+	if err := run(SortFlags{quietCheck: true}, names[:1], nil); err != nil {
+		t.Errorf("run() с -C = %v; want nil", err)
+	}
+}
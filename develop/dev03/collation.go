@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// collator сравнивает строки по правилам локали: алгоритм сортировки
+// Unicode (UCA) с поправками CLDR для языка. collate.Collator нельзя
+// использовать из нескольких горутин, поэтому экземпляры берутся из пула.
+type collator struct {
+	pool sync.Pool
+}
+
+// newCollator создает сравнение для локали вида "ru", "ru-RU" или
+// "ru_RU.UTF-8". Для локалей C и POSIX возвращается nil: строки
+// сравниваются побайтово.
+func newCollator(locale string) (*collator, error) {
+	locale, _, _ = strings.Cut(locale, ".")
+	if locale == "" || locale == "C" || locale == "POSIX" {
+		return nil, nil
+	}
+
+	tag, err := language.Parse(strings.ReplaceAll(locale, "_", "-"))
+	if err != nil {
+		return nil, fmt.Errorf("неизвестная локаль %q", locale)
+	}
+
+	c := &collator{}
+	c.pool.New = func() any { return collate.New(tag) }
+	return c, nil
+}
+
+// compare сравнивает строки; результат как у strings.Compare. Без локали
+// (c == nil) сравнение побайтовое.
+func (c *collator) compare(a, b string) int {
+	if c == nil {
+		return strings.Compare(a, b)
+	}
+	col := c.pool.Get().(*collate.Collator)
+	defer c.pool.Put(col)
+	return col.CompareString(a, b)
+}
+
+// dictionaryOrder оставляет в строке только буквы, цифры и пробелы (флаг -d)
+func dictionaryOrder(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == ' ' || r == '\t' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// compareVersion сравнивает строки как номера версий или имена файлов с
+// числами (флаг -V), по правилам dpkg: последовательности цифр
+// сравниваются как числа, остальные символы — посимвольно, причем буквы
+// идут раньше прочих символов, а тильда — раньше всего, даже конца строки.
+// Поэтому "file2" < "file10", "1.9" < "1.10" и "1.0~rc1" < "1.0".
+func compareVersion(a, b string) int {
+	for a != "" || b != "" {
+		for (a != "" && !isDigit(a[0])) || (b != "" && !isDigit(b[0])) {
+			ac, bc := versionOrder(a), versionOrder(b)
+			if ac != bc {
+				return compareInt(ac, bc)
+			}
+			a, b = a[1:], b[1:]
+		}
+
+		a = strings.TrimLeft(a, "0")
+		b = strings.TrimLeft(b, "0")
+		firstDiff := 0
+		for a != "" && b != "" && isDigit(a[0]) && isDigit(b[0]) {
+			if firstDiff == 0 {
+				firstDiff = compareInt(int(a[0]), int(b[0]))
+			}
+			a, b = a[1:], b[1:]
+		}
+		switch {
+		case a != "" && isDigit(a[0]):
+			return 1
+		case b != "" && isDigit(b[0]):
+			return -1
+		case firstDiff != 0:
+			return firstDiff
+		}
+	}
+	return 0
+}
+
+// versionOrder возвращает вес первого байта строки для compareVersion
+func versionOrder(s string) int {
+	switch {
+	case s == "" || isDigit(s[0]):
+		return 0
+	case s[0] == '~':
+		return -1
+	case s[0] < 0x80 && unicode.IsLetter(rune(s[0])):
+		return int(s[0])
+	}
+	return int(s[0]) + 256
+}
+
+// isDigit проверяет, является ли байт десятичной цифрой
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
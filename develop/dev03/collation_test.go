@@ -0,0 +1,109 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Тестируем сравнение по правилам локали
+func TestSortLinesLocale(t *testing.T) {
+	ru, err := newCollator("ru_RU.UTF-8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := []string{"ёж", "Яблоко", "жук", "еда", "арбуз", "Ель"}
+	expected := []string{"арбуз", "еда", "ёж", "Ель", "жук", "Яблоко"}
+	got := sortLines(append([]string(nil), lines...), SortFlags{collator: ru})
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("sortLines() с локалью ru = %v; want %v", got, expected)
+	}
+
+	// Побайтово ё и заглавные буквы стоят не на своих местах
+	expected = []string{"Ель", "Яблоко", "арбуз", "еда", "жук", "ёж"}
+	got = sortLines(append([]string(nil), lines...), SortFlags{})
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("sortLines() без локали = %v; want %v", got, expected)
+	}
+
+	en, _ := newCollator("en")
+	lines = []string{"fig", "éclair", "Eclair", "eclair", "apple"}
+	expected = []string{"apple", "eclair", "Eclair", "éclair", "fig"}
+	got = sortLines(append([]string(nil), lines...), SortFlags{collator: en})
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("sortLines() с локалью en = %v; want %v", got, expected)
+	}
+
+	for _, locale := range []string{"", "C", "POSIX", "C.UTF-8"} {
+		if c, err := newCollator(locale); c != nil || err != nil {
+			t.Errorf("newCollator(%q) = %v, %v; want nil, nil", locale, c, err)
+		}
+	}
+	if _, err := newCollator("??"); err == nil {
+		t.Error("newCollator(\"??\"): ожидалась ошибка")
+	}
+}
+
+// Тестируем флаги -f и -d
+func TestSortLinesFoldDictionary(t *testing.T) {
+	lines := []string{"b", "B", "a", "C", "A"}
+	expected := []string{"A", "a", "B", "b", "C"}
+	got := sortLines(append([]string(nil), lines...), SortFlags{foldCase: true})
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("sortLines() с -f = %v; want %v", got, expected)
+	}
+
+	lines = []string{"(b)", "a-c", "[a]b", "ab"}
+	expected = []string{"[a]b", "ab", "a-c", "(b)"}
+	got = sortLines(append([]string(nil), lines...), SortFlags{dictionary: true})
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("sortLines() с -d = %v; want %v", got, expected)
+	}
+
+	// Ключ с опциями f и d
+	var keys keyList
+	keys.Set("2df")
+	lines = []string{"1 B.", "2 a!", "3 _c"}
+	expected = []string{"2 a!", "1 B.", "3 _c"}
+	got = sortLines(append([]string(nil), lines...), SortFlags{keys: keys})
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("sortLines() с -k2df = %v; want %v", got, expected)
+	}
+}
+
+// Тестируем сравнение номеров версий
+func TestCompareVersion(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"file2", "file10", -1},
+		{"1.9", "1.10", -1},
+		{"1.10", "1.10", 0},
+		{"1.010", "1.10", 0},
+		{"1.0~rc1", "1.0", -1},
+		{"1.0", "1.0a", -1},
+		{"1.0a", "1.0+", -1},
+		{"2.0", "10.0", -1},
+		{"v1.2.3", "v1.2.10", -1},
+		{"123456789012345678901234567890", "123456789012345678901234567891", -1},
+		{"", "a", -1},
+		{"a", "b", -1},
+	}
+
+	for _, tt := range tests {
+		if got := compareVersion(tt.a, tt.b); got != tt.expected {
+			t.Errorf("compareVersion(%q, %q) = %d; want %d", tt.a, tt.b, got, tt.expected)
+		}
+		if got := compareVersion(tt.b, tt.a); got != -tt.expected {
+			t.Errorf("compareVersion(%q, %q) = %d; want %d", tt.b, tt.a, got, -tt.expected)
+		}
+	}
+
+	lines := []string{"linux-6.10.tar", "linux-6.9.tar", "linux-6.1.tar", "linux-5.15.tar"}
+	expected := []string{"linux-5.15.tar", "linux-6.1.tar", "linux-6.9.tar", "linux-6.10.tar"}
+	got := sortLines(lines, SortFlags{version: true})
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("sortLines() с -V = %v; want %v", got, expected)
+	}
+}
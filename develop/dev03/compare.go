@@ -0,0 +1,218 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// globalOptions возвращает параметры сравнения, заданные глобальными флагами
+func (sf SortFlags) globalOptions() KeyOptions {
+	return KeyOptions{
+		numeric:      sf.numeric,
+		reverse:      sf.reverse,
+		month:        sf.month,
+		human:        sf.human,
+		version:      sf.version,
+		ignoreBlanks: sf.ignoreBlanks,
+		foldCase:     sf.foldCase,
+		dictionary:   sf.dictionary,
+		random:       sf.random,
+	}
+}
+
+// bytewise сообщает, что строки сравниваются целиком и побайтово: не заданы
+// ни ключи, ни опции сравнения, ни локаль
+func (sf SortFlags) bytewise() bool {
+	return len(sf.keys) == 0 && sf.globalOptions().isZero() && sf.collator == nil
+}
+
+// compareLines сравнивает строки по ключам, а при их равенстве — целиком по
+// правилам локали и затем побайтово с учетом флага -r. С флагами -s и -u
+// последнее сравнение не выполняется: строки с равными ключами сохраняют
+// исходный порядок, и -u оставляет первую из них.
+func compareLines(a, b string, sf SortFlags) int {
+	if c := compareKeys(a, b, sf); c != 0 || sf.stable || sf.unique {
+		return c
+	}
+
+	c := sf.collator.compare(a, b)
+	if c == 0 {
+		c = strings.Compare(a, b)
+	}
+	if sf.reverse {
+		c = -c
+	}
+	return c
+}
+
+// compareKeys сравнивает строки по ключам по порядку: каждый следующий ключ
+// разрешает равенство предыдущих. Ключ без собственных опций наследует
+// глобальные. Без ключей ключом служит строка целиком.
+func compareKeys(a, b string, sf SortFlags) int {
+	global := sf.globalOptions()
+
+	if len(sf.keys) == 0 {
+		return compareKey(a, b, global, sf)
+	}
+
+	for _, k := range sf.keys {
+		opts := k.opts
+		if opts.isZero() {
+			opts = global
+		}
+		if c := compareKey(sf.extractKey(k, a, opts), sf.extractKey(k, b, opts), opts, sf); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// compareKey сравнивает значения ключей; результат как у strings.Compare.
+// Строковые ключи сравниваются по правилам локали sf.collator, а с опцией
+// R — сначала по случайному хешу с солью sf.randomSalt.
+func compareKey(a, b string, o KeyOptions, sf SortFlags) int {
+	if o.ignoreBlanks {
+		a = strings.TrimSpace(a)
+		b = strings.TrimSpace(b)
+	}
+
+	var c int
+	switch {
+	case o.numeric:
+		c = compareNumeric(a, b)
+	case o.month:
+		c = compareBy(a, b, monthLess)
+	case o.human:
+		c = compareHuman(a, b)
+	case o.version:
+		c = compareVersion(a, b)
+	default:
+		if o.dictionary {
+			a, b = dictionaryOrder(a), dictionaryOrder(b)
+		}
+		if o.foldCase {
+			a, b = strings.ToUpper(a), strings.ToUpper(b)
+		}
+		if o.random {
+			c = compareRandom(a, b, sf.randomSalt)
+		}
+		if c == 0 {
+			c = sf.collator.compare(a, b)
+		}
+	}
+
+	if o.reverse {
+		c = -c
+	}
+	return c
+}
+
+// compareBy превращает функцию «меньше» в трехзначное сравнение
+func compareBy(a, b string, less func(a, b string) bool) int {
+	switch {
+	case less(a, b):
+		return -1
+	case less(b, a):
+		return 1
+	}
+	return 0
+}
+
+// compareNumeric сравнивает числовые префиксы строк. Строка без числа,
+// как и в GNU sort, считается нулем.
+func compareNumeric(a, b string) int {
+	return compareFloat(numericPrefix(a), numericPrefix(b))
+}
+
+// compareFloat сравнивает два числа
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// numericPrefix разбирает число в начале строки после пробелов:
+// необязательный знак минус, цифры и дробную часть после точки. Для
+// строки без числа возвращает ноль.
+func numericPrefix(s string) float64 {
+	n, _, _ := parseNumber(s)
+	return n
+}
+
+// parseNumber разбирает число так же, как numericPrefix, и возвращает
+// остаток строки после него
+func parseNumber(s string) (float64, string, bool) {
+	s = strings.TrimLeft(s, " \t")
+	i := 0
+	if i < len(s) && s[i] == '-' {
+		i++
+	}
+	digits := 0
+	for ; i < len(s) && s[i] >= '0' && s[i] <= '9'; i++ {
+		digits++
+	}
+	if i < len(s) && s[i] == '.' {
+		for i++; i < len(s) && s[i] >= '0' && s[i] <= '9'; i++ {
+			digits++
+		}
+	}
+	if digits == 0 {
+		return 0, s, false
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSuffix(s[:i], "."), 64)
+	return n, s[i:], err == nil
+}
+
+// unitOrder задает порядок суффиксов СИ для флага -h
+var unitOrder = map[byte]int{
+	'k': 1, 'K': 1, 'M': 2, 'G': 3, 'T': 4, 'P': 5, 'E': 6, 'Z': 7, 'Y': 8, 'R': 9, 'Q': 10,
+}
+
+// humanPrefix разбирает число с необязательным суффиксом СИ, как в выводе
+// du -h: "1.5K", "-3M", "2GiB". Возвращает порядок суффикса со знаком числа
+// (у нуля порядок нулевой) и само число без учета суффикса. Строка без
+// числа, как в find_unit_order из GNU sort, считается нулем без суффикса.
+func humanPrefix(s string) (int, float64) {
+	n, rest, ok := parseNumber(s)
+	if !ok {
+		return 0, 0
+	}
+
+	var order int
+	if rest != "" && n != 0 {
+		order = unitOrder[rest[0]]
+	}
+	if n < 0 {
+		order = -order
+	}
+	return order, n
+}
+
+// compareHuman сравнивает числа с суффиксами так же, как GNU sort -h:
+// сначала по знаку и суффиксу, затем по значению числа, поэтому "2K"
+// больше "1000", а "1M" больше "1500K". Строки без числа равны нулю:
+// "-1K" < "abc" = "0" < "1K".
+func compareHuman(a, b string) int {
+	aOrder, aNum := humanPrefix(a)
+	bOrder, bNum := humanPrefix(b)
+	if aOrder != bOrder {
+		return compareInt(aOrder, bOrder)
+	}
+	return compareFloat(aNum, bNum)
+}
+
+// compareInt сравнивает два целых числа
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Тестируем сравнение чисел с суффиксами СИ
+func TestCompareHuman(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"1K", "2K", -1},
+		{"2K", "1000", 1},
+		{"1M", "1500K", 1},
+		{"1.5G", "1G", 1},
+		{"1KiB", "1K", 0},
+		{"1k", "1K", 0},
+		{"1MB", "2M", -1},
+		{"-1M", "-1K", -1},
+		{"-1K", "-2", -1},
+		{"-5", "0", -1},
+		{"0K", "0", 0},
+		{"0.5", "1", -1},
+		{"  3T", "4T", -1},
+		{"1P", "1T", 1},
+		{"1E", "1P", 1},
+		{"1X", "1", 0},
+		{"abc", "1K", -1},
+		{"abc", "-1K", 1},
+		{"abc", "0", 0},
+		{"abc", "0.5", -1},
+		{"abc", "-0.5", 1},
+		{"abc", "xyz", 0},
+	}
+
+	for _, tt := range tests {
+		if got := compareHuman(tt.a, tt.b); got != tt.expected {
+			t.Errorf("compareHuman(%q, %q) = %d; want %d", tt.a, tt.b, got, tt.expected)
+		}
+		if got := compareHuman(tt.b, tt.a); got != -tt.expected {
+			t.Errorf("compareHuman(%q, %q) = %d; want %d", tt.b, tt.a, got, -tt.expected)
+		}
+	}
+}
+
+// Тестируем сортировку вывода du -h
+func TestSortLinesHuman(t *testing.T) {
+	lines := []string{
+		"1.2G\t./video",
+		"4.0K\t./notes",
+		"512\t./empty",
+		"980M\t./music",
+		"12K\t./docs",
+		"2.5M\t./photos",
+	}
+	expected := []string{
+		"512\t./empty",
+		"4.0K\t./notes",
+		"12K\t./docs",
+		"2.5M\t./photos",
+		"980M\t./music",
+		"1.2G\t./video",
+	}
+
+	got := sortLines(append([]string(nil), lines...), SortFlags{human: true})
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("sortLines() = %q; want %q", got, expected)
+	}
+
+	var keys keyList
+	keys.Set("1hr,1")
+	got = sortLines(append([]string(nil), lines...), SortFlags{keys: keys, separator: '\t'})
+	for i, j := 0, len(expected)-1; i < j; i, j = i+1, j-1 {
+		expected[i], expected[j] = expected[j], expected[i]
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("sortLines() с -k1hr,1 = %q; want %q", got, expected)
+	}
+}
+
+// Тестируем сочетание -r, -u и -s со всеми способами сравнения
+func TestSortLinesMatrix(t *testing.T) {
+	lines := []string{
+		"10 Feb 1.5K v1.10 b-x", "9 jan 2M v1.9 B", "10 Mar 1500 v1.2 a",
+		"x Feb 1.5K v1.10 b-x", "010 Dec 3G v2.0 c", "9 jan 2M v1.9 b",
+		"-1 Jan 0 v0.9 (a)", "10 Feb 1.5K v1.10 b-x ", " 9 foo 12K v1.9~rc1 A",
+	}
+
+	modes := map[string]func(*SortFlags){
+		"строки": func(*SortFlags) {},
+		"-n":     func(sf *SortFlags) { sf.numeric = true },
+		"-h":     func(sf *SortFlags) { sf.human = true },
+		"-M":     func(sf *SortFlags) { sf.month = true },
+		"-V":     func(sf *SortFlags) { sf.version = true },
+		"-f":     func(sf *SortFlags) { sf.foldCase = true },
+		"-d":     func(sf *SortFlags) { sf.dictionary = true },
+		"-bf":    func(sf *SortFlags) { sf.ignoreBlanks, sf.foldCase = true, true },
+	}
+	fields := map[string]string{
+		"строки": "1,1", "-n": "1,1", "-h": "3,3", "-M": "2,2",
+		"-V": "4,4", "-f": "5,5", "-d": "5,5", "-bf": "5,5",
+	}
+
+	for name, mode := range modes {
+		for _, withKey := range []bool{false, true} {
+			for flags := 0; flags < 8; flags++ {
+				sf := SortFlags{reverse: flags&1 != 0, unique: flags&2 != 0, stable: flags&4 != 0}
+				mode(&sf)
+				if withKey {
+					sf.keys.Set(fields[name])
+				}
+				desc := fmt.Sprintf("%s, ключ %v, -r %v, -u %v, -s %v", name, withKey, sf.reverse, sf.unique, sf.stable)
+
+				got := sortLines(append([]string(nil), lines...), sf)
+				checkSortedMatrix(t, desc, lines, got, sf)
+
+				// С -r порядок ключей обратный порядку без -r
+				forward := sf
+				forward.reverse = false
+				if sf.reverse {
+					want := sortLines(append([]string(nil), lines...), forward)
+					if len(want) != len(got) {
+						t.Errorf("%s: с -r получено %d строк, без -r — %d", desc, len(got), len(want))
+						continue
+					}
+					for i := range got {
+						if compareKeys(got[i], want[len(want)-1-i], forward) != 0 {
+							t.Errorf("%s: с -r ключи идут не в обратном порядке: %q", desc, got)
+							break
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// checkSortedMatrix проверяет результат сортировки lines с флагами sf
+func checkSortedMatrix(t *testing.T, desc string, lines, got []string, sf SortFlags) {
+	t.Helper()
+
+	// Строки во входных данных различны, поэтому позиция определяется строкой
+	position := make(map[string]int)
+	for i, line := range lines {
+		position[line] = i
+	}
+
+	for i := 1; i < len(got); i++ {
+		a, b := got[i-1], got[i]
+		c := compareKeys(a, b, sf)
+		switch {
+		case c > 0:
+			t.Errorf("%s: строки %q и %q идут не по порядку: %q", desc, a, b, got)
+		case c == 0 && sf.unique:
+			t.Errorf("%s: строки %q и %q с равными ключами не удалены: %q", desc, a, b, got)
+		case c == 0 && sf.stable && position[a] > position[b]:
+			t.Errorf("%s: нарушен исходный порядок строк %q и %q: %q", desc, a, b, got)
+		case c == 0 && !sf.stable && (strings.Compare(a, b) > 0) != sf.reverse && a != b:
+			t.Errorf("%s: строки %q и %q с равными ключами не упорядочены целиком: %q", desc, a, b, got)
+		}
+	}
+
+	if sf.unique {
+		// Из строк с равными ключами остается первая во входных данных
+		for _, line := range got {
+			for _, prev := range lines[:position[line]] {
+				if compareKeys(prev, line, sf) == 0 {
+					t.Errorf("%s: вместо %q оставлена %q", desc, prev, line)
+				}
+			}
+		}
+	} else if len(got) != len(lines) {
+		t.Errorf("%s: получено %d строк; want %d", desc, len(got), len(lines))
+	}
+}
+
+// Тестируем -r, -u и -s на конкретных примерах
+func TestSortLinesReverseUniqueStable(t *testing.T) {
+	key := func(s string) keyList {
+		var keys keyList
+		keys.Set(s)
+		return keys
+	}
+
+	tests := []struct {
+		name     string
+		lines    []string
+		sf       SortFlags
+		expected []string
+	}{
+		{"-nr", []string{"10", "9", "100", "x"}, SortFlags{numeric: true, reverse: true}, []string{"100", "10", "9", "x"}},
+		{"-n без числа", []string{"abc", "-1", "1"}, SortFlags{numeric: true}, []string{"-1", "abc", "1"}},
+		{"-t: -k3n пустое поле", []string{"a:b:2", "c:d:", "e:f:-1"}, SortFlags{keys: key("3n"), separator: ':'}, []string{"e:f:-1", "c:d:", "a:b:2"}},
+		{"-hr", []string{"1K", "2M", "512"}, SortFlags{human: true, reverse: true}, []string{"2M", "1K", "512"}},
+		{"-h без числа", []string{"abc", "1K", "-1K", "0"}, SortFlags{human: true}, []string{"-1K", "0", "abc", "1K"}},
+		{"-Mr", []string{"Jan", "Mar", "Feb"}, SortFlags{month: true, reverse: true}, []string{"Mar", "Feb", "Jan"}},
+		{"-Vr", []string{"1.9", "1.10", "1.2"}, SortFlags{version: true, reverse: true}, []string{"1.10", "1.9", "1.2"}},
+		{"-n -u", []string{"1", "01", "2", "1.0"}, SortFlags{numeric: true, unique: true}, []string{"1", "2"}},
+		{"-u -k2,2", []string{"b 1", "a 1", "c 2"}, SortFlags{keys: key("2,2"), unique: true}, []string{"b 1", "c 2"}},
+		{"-s -k2,2", []string{"b 1", "a 1", "c 0"}, SortFlags{keys: key("2,2"), stable: true}, []string{"c 0", "b 1", "a 1"}},
+		{"-rs -k2,2", []string{"b 1", "a 1", "c 0"}, SortFlags{keys: key("2,2"), stable: true, reverse: true}, []string{"b 1", "a 1", "c 0"}},
+		{"-k2,2", []string{"b 1", "a 1", "c 0"}, SortFlags{keys: key("2,2")}, []string{"c 0", "a 1", "b 1"}},
+		{"-r -k2,2", []string{"a 1", "b 1", "c 0"}, SortFlags{keys: key("2,2"), reverse: true}, []string{"b 1", "a 1", "c 0"}},
+		{"-f -u", []string{"b", "A", "a", "B"}, SortFlags{foldCase: true, unique: true}, []string{"A", "b"}},
+	}
+
+	for _, tt := range tests {
+		got := sortLines(append([]string(nil), tt.lines...), tt.sf)
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%s: sortLines(%q) = %q; want %q", tt.name, tt.lines, got, tt.expected)
+		}
+	}
+}
+
+// Тестируем распознавание названий месяцев
+func TestMonthNumber(t *testing.T) {
+	tests := map[string]int{
+		"Jan":         1,
+		"jan":         1,
+		"JANUARY":     1,
+		"  Feb 2024":  2,
+		"\tSept":      9,
+		"september":   9,
+		"Dec.":        12,
+		"янв":         1,
+		"Февраль":     2,
+		"марта":       3,
+		"май":         5,
+		"мая":         5,
+		"июн":         6,
+		"июля":        7,
+		"ноябрь 2023": 11,
+		"Ja":          0,
+		"":            0,
+		"foo":         0,
+		"Janfoo":      0,
+		"15 Jan":      0,
+		"Mayday":      0,
+		"декабрьский": 0,
+	}
+
+	for input, expected := range tests {
+		if got := monthNumber(input); got != expected {
+			t.Errorf("monthNumber(%q) = %d; want %d", input, got, expected)
+		}
+	}
+}
+
+// Тестируем сортировку по месяцам с ключами
+func TestSortLinesMonth(t *testing.T) {
+	lines := []string{"3 Mar", "1 январь", "5 ???", "4 february", "2 DEC", "6 июня"}
+	var keys keyList
+	keys.Set("2M")
+	expected := []string{"5 ???", "1 январь", "4 february", "3 Mar", "6 июня", "2 DEC"}
+	got := sortLines(append([]string(nil), lines...), SortFlags{keys: keys})
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("sortLines() с -k2M = %q; want %q", got, expected)
+	}
+
+	keys = nil
+	keys.Set("2Mr")
+	for i, j := 0, len(expected)-1; i < j; i, j = i+1, j-1 {
+		expected[i], expected[j] = expected[j], expected[i]
+	}
+	got = sortLines(append([]string(nil), lines...), SortFlags{keys: keys})
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("sortLines() с -k2Mr = %q; want %q", got, expected)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// csvSeparator возвращает разделитель полей в режиме CSV: заданный флагом
+// -t или запятую
+func (sf SortFlags) csvSeparator() rune {
+	if sf.separator != 0 {
+		return rune(sf.separator)
+	}
+	return ','
+}
+
+// extractKey извлекает ключ k из строки; в режиме CSV поля разбираются по
+// RFC 4180 и ключ составляется из их значений без кавычек
+func (sf SortFlags) extractKey(k KeyDef, line string, opts KeyOptions) string {
+	if sf.csv {
+		sep := sf.csvSeparator()
+		return k.extractValues(csvFields(line, sep), sep, opts)
+	}
+	return k.extract(line, rune(sf.separator), opts)
+}
+
+// extractValues возвращает ключ, составленный из значений полей; значения
+// соседних полей соединяются разделителем sep
+func (k KeyDef) extractValues(fields []string, sep rune, opts KeyOptions) string {
+	if k.startField > len(fields) {
+		return ""
+	}
+	last := len(fields)
+	if k.endField > 0 && k.endField < last {
+		last = k.endField
+	}
+
+	var b strings.Builder
+	for i := k.startField; i <= last; i++ {
+		v := fields[i-1]
+		begin, end := 0, len(v)
+		if i == k.startField {
+			if opts.ignoreBlanks {
+				begin = skipBlanks(v, begin, end)
+			}
+			begin = skipChars(v, begin, end, k.startChar-1)
+		}
+		if i == k.endField && k.endChar > 0 {
+			pos := 0
+			if opts.ignoreBlanks {
+				pos = skipBlanks(v, pos, len(v))
+			}
+			end = skipChars(v, pos, len(v), k.endChar)
+		}
+
+		if i > k.startField {
+			b.WriteRune(sep)
+		}
+		if begin < end {
+			b.WriteString(v[begin:end])
+		}
+	}
+	return b.String()
+}
+
+// csvFields разбирает запись CSV по RFC 4180: поле в двойных кавычках может
+// содержать разделитель и перевод строки, а кавычка внутри него удваивается.
+// Разбор нестрогий: незакрытая кавычка продолжается до конца записи, а
+// символы после закрывающей кавычки добавляются к значению.
+func csvFields(record string, sep rune) []string {
+	var fields []string
+	var b strings.Builder
+	for {
+		b.Reset()
+		if strings.HasPrefix(record, `"`) {
+			record = record[1:]
+			for {
+				i := strings.IndexByte(record, '"')
+				if i < 0 {
+					b.WriteString(record)
+					record = ""
+					break
+				}
+				b.WriteString(record[:i])
+				record = record[i+1:]
+				if !strings.HasPrefix(record, `"`) {
+					break
+				}
+				b.WriteByte('"')
+				record = record[1:]
+			}
+		}
+
+		i := strings.IndexRune(record, sep)
+		if i < 0 {
+			b.WriteString(record)
+			return append(fields, b.String())
+		}
+		b.WriteString(record[:i])
+		fields = append(fields, b.String())
+		record = record[i+utf8.RuneLen(sep):]
+	}
+}
+
+// scanCSVRecords — функция разбиения для bufio.Scanner, выделяющая записи
+// CSV: перевод строки внутри кавычек запись не завершает. Завершающий
+// возврат каретки отбрасывается.
+func scanCSVRecords(data []byte, atEOF bool) (int, []byte, error) {
+	quoted := false
+	for i, c := range data {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == '\n' && !quoted:
+			return i + 1, dropCR(data[:i]), nil
+		}
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), dropCR(data), nil
+	}
+	return 0, nil, nil
+}
+
+// dropCR отбрасывает завершающий возврат каретки
+func dropCR(data []byte) []byte {
+	if len(data) > 0 && data[len(data)-1] == '\r' {
+		return data[:len(data)-1]
+	}
+	return data
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bufio"
+	"container/heap"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// mergeFanIn — максимальное число серий, сливаемых за один проход
+const mergeFanIn = 64
+
+// lineOverhead — оценка памяти, занимаемой строкой помимо ее байтов
+const lineOverhead = 16
+
+// sizeSuffixes сопоставляет суффиксам размера множители
+var sizeSuffixes = map[byte]int64{
+	'b': 1,
+	'k': 1 << 10, 'K': 1 << 10,
+	'm': 1 << 20, 'M': 1 << 20,
+	'g': 1 << 30, 'G': 1 << 30,
+	't': 1 << 40, 'T': 1 << 40,
+}
+
+// sizeValue — значение флага -S: размер с необязательным суффиксом
+type sizeValue int64
+
+// String реализует flag.Value
+func (v *sizeValue) String() string {
+	return strconv.FormatInt(int64(*v), 10)
+}
+
+// Set реализует flag.Value. Суффиксы b, K, M, G, T задают байты, кибибайты и
+// т. д.; число без суффикса, как в GNU sort, означает кибибайты.
+func (v *sizeValue) Set(s string) error {
+	num, mult := s, int64(1<<10)
+	if s != "" {
+		if m, ok := sizeSuffixes[s[len(s)-1]]; ok {
+			num, mult = s[:len(s)-1], m
+		}
+	}
+
+	n, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || n <= 0 || n > (1<<62)/mult {
+		return fmt.Errorf("некорректный размер буфера %q", s)
+	}
+	*v = sizeValue(n * mult)
+	return nil
+}
+
+// runFile — отсортированная серия строк во временном файле
+type runFile struct {
+	name string
+}
+
+// externalSort сортирует строки из readers и пишет результат в w. Строки
+// накапливаются в буфере размером bufSize; при его заполнении буфер
+// сортируется и сбрасывается во временный файл в каталоге tmpDir, после
+// чего серии сливаются. Результат совпадает с сортировкой в памяти.
+func externalSort(readers []io.Reader, w io.Writer, sf SortFlags, bufSize int64, tmpDir string) (err error) {
+	var runs, merged []runFile
+	defer func() {
+		for _, run := range append(runs, merged...) {
+			os.Remove(run.name)
+		}
+	}()
+
+	var chunk []string
+	var size int64
+	for _, r := range readers {
+		scanner := newRecordScanner(r, sf)
+		for scanner.Scan() {
+			line := scanner.Text()
+			chunk = append(chunk, line)
+			size += int64(len(line)) + lineOverhead
+			if size < bufSize {
+				continue
+			}
+
+			run, err := writeRun(chunk, sf, tmpDir)
+			if err != nil {
+				return err
+			}
+			runs = append(runs, run)
+			chunk, size = nil, 0
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	emit := func(line string) error {
+		return writeRecord(bw, line, sf)
+	}
+
+	// Все данные поместились в буфер: сортируем в памяти
+	if len(runs) == 0 {
+		for _, line := range sortLines(chunk, sf) {
+			if err := emit(line); err != nil {
+				return err
+			}
+		}
+		return bw.Flush()
+	}
+
+	if len(chunk) > 0 {
+		run, err := writeRun(chunk, sf, tmpDir)
+		if err != nil {
+			return err
+		}
+		runs = append(runs, run)
+	}
+
+	// Сливаем серии группами, пока их не останется не больше mergeFanIn
+	for len(runs) > mergeFanIn {
+		for i := 0; i < len(runs); i += mergeFanIn {
+			end := min(i+mergeFanIn, len(runs))
+			run, err := mergeToRun(runs[i:end], sf, tmpDir)
+			if err != nil {
+				return err
+			}
+			merged = append(merged, run)
+		}
+		for _, run := range runs {
+			os.Remove(run.name)
+		}
+		runs, merged = merged, nil
+	}
+
+	if err := mergeRunFiles(runs, sf, sf.unique, emit); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// writeRun сортирует строки и записывает их во временный файл. При ошибке
+// файл удаляется.
+func writeRun(lines []string, sf SortFlags, tmpDir string) (runFile, error) {
+	// Серия сохраняет все строки в том порядке, который дала бы сортировка с -u
+	sf.stable = sf.stable || sf.unique
+	sf.unique = false
+	lines = sortLines(lines, sf)
+
+	f, err := os.CreateTemp(tmpDir, "sort-run-*")
+	if err != nil {
+		return runFile{}, err
+	}
+	run := runFile{name: f.Name()}
+
+	bw := bufio.NewWriter(f)
+	for _, line := range lines {
+		writeRecord(bw, line, sf)
+	}
+	err = bw.Flush()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(run.name)
+		return runFile{}, err
+	}
+	return run, nil
+}
+
+// mergeToRun сливает несколько серий в новую серию. При ошибке файл новой
+// серии удаляется.
+func mergeToRun(runs []runFile, sf SortFlags, tmpDir string) (runFile, error) {
+	f, err := os.CreateTemp(tmpDir, "sort-run-*")
+	if err != nil {
+		return runFile{}, err
+	}
+	run := runFile{name: f.Name()}
+
+	bw := bufio.NewWriter(f)
+	err = mergeRunFiles(runs, sf, false, func(line string) error {
+		return writeRecord(bw, line, sf)
+	})
+	if err == nil {
+		err = bw.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(run.name)
+		return runFile{}, err
+	}
+	return run, nil
+}
+
+// mergeRunFiles открывает файлы серий и сливает их
+func mergeRunFiles(runs []runFile, sf SortFlags, unique bool, emit func(string) error) error {
+	scanners := make([]*bufio.Scanner, 0, len(runs))
+	for _, run := range runs {
+		f, err := os.Open(run.name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		scanners = append(scanners, newRecordScanner(f, sf))
+	}
+	return mergeReaders(scanners, sf, unique, emit)
+}
+
+// mergeItem — текущая строка одного из сливаемых источников
+type mergeItem struct {
+	line    string
+	index   int
+	scanner *bufio.Scanner
+}
+
+// mergeHeap — куча текущих строк источников; при равенстве строк первым
+// идет источник с меньшим номером, что сохраняет стабильность сортировки
+type mergeHeap struct {
+	items []*mergeItem
+	sf    SortFlags
+}
+
+func (h *mergeHeap) Len() int { return len(h.items) }
+
+func (h *mergeHeap) Less(i, j int) bool {
+	if c := compareLines(h.items[i].line, h.items[j].line, h.sf); c != 0 {
+		return c < 0
+	}
+	return h.items[i].index < h.items[j].index
+}
+
+func (h *mergeHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *mergeHeap) Push(x any) { h.items = append(h.items, x.(*mergeItem)) }
+
+func (h *mergeHeap) Pop() any {
+	item := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return item
+}
+
+// mergeReaders выполняет k-путевое слияние отсортированных источников. При
+// unique из каждой серии строк с равными ключами выводится только первая.
+func mergeReaders(scanners []*bufio.Scanner, sf SortFlags, unique bool, emit func(string) error) error {
+	h := &mergeHeap{sf: sf}
+	for i, scanner := range scanners {
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return err
+			}
+			continue
+		}
+		h.items = append(h.items, &mergeItem{line: scanner.Text(), index: i, scanner: scanner})
+	}
+	heap.Init(h)
+
+	var last string
+	var emitted bool
+	for h.Len() > 0 {
+		item := h.items[0]
+		if !unique || !emitted || !equalForUnique(last, item.line, sf) {
+			if err := emit(item.line); err != nil {
+				return err
+			}
+			last, emitted = item.line, true
+		}
+
+		if item.scanner.Scan() {
+			item.line = item.scanner.Text()
+			heap.Fix(h, 0)
+			continue
+		}
+		if err := item.scanner.Err(); err != nil {
+			return err
+		}
+		heap.Pop(h)
+	}
+	return nil
+}
+
+// equalForUnique сообщает, считаются ли строки повторами при флаге -u
+func equalForUnique(a, b string, sf SortFlags) bool {
+	if sf.bytewise() {
+		return a == b
+	}
+	return compareKeys(a, b, sf) == 0
+}
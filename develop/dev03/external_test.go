@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// Тестируем совпадение внешней сортировки с сортировкой в памяти
+func TestExternalSort(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var lines []string
+	for i := 0; i < 5000; i++ {
+		lines = append(lines, fmt.Sprintf("%c%d %d", 'a'+rng.Intn(26), rng.Intn(100), rng.Intn(1000)))
+	}
+	input := strings.Join(lines, "\n") + "\n"
+
+	var keys keyList
+	keys.Set("2n")
+
+	tests := []struct {
+		name string
+		sf   SortFlags
+	}{
+		{"без флагов", SortFlags{}},
+		{"-r", SortFlags{reverse: true}},
+		{"-u", SortFlags{unique: true}},
+		{"-k2n", SortFlags{keys: keys}},
+		{"-k2n -u", SortFlags{keys: keys, unique: true}},
+		{"-k2n -r -u", SortFlags{keys: keys, reverse: true, unique: true}},
+	}
+
+	// Буфер в 1 KiB дает около сотни серий, больше mergeFanIn
+	for _, bufSize := range []int64{1 << 10, 1 << 14, 1 << 30} {
+		for _, tt := range tests {
+			dir := t.TempDir()
+			var out strings.Builder
+			if err := externalSort([]io.Reader{strings.NewReader(input)}, &out, tt.sf, bufSize, dir); err != nil {
+				t.Fatalf("%s, буфер %d: externalSort() вернула ошибку: %v", tt.name, bufSize, err)
+			}
+
+			expected := sortLines(append([]string(nil), lines...), tt.sf)
+			got := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("%s, буфер %d: результат внешней сортировки отличается от сортировки в памяти", tt.name, bufSize)
+			}
+
+			if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+				t.Errorf("%s, буфер %d: не удалено временных файлов: %d", tt.name, bufSize, len(entries))
+			}
+		}
+	}
+}
+
+// Тестируем удаление временных файлов при ошибках
+func TestExternalSortErrorCleanup(t *testing.T) {
+	input := strings.Repeat("line\n", 1000)
+	readErr := errors.New("ошибка чтения")
+
+	// Ошибка чтения после записи нескольких серий
+	dir := t.TempDir()
+	r := io.MultiReader(strings.NewReader(input), iotest.ErrReader(readErr))
+	if err := externalSort([]io.Reader{r}, io.Discard, SortFlags{}, 1<<10, dir); !errors.Is(err, readErr) {
+		t.Errorf("externalSort() = %v; want %v", err, readErr)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("externalSort(): не удалено временных файлов: %d", len(entries))
+	}
+
+	// Ошибка слияния: файл новой серии не должен остаться
+	dir = t.TempDir()
+	missing := runFile{name: dir + "/missing"}
+	if _, err := mergeToRun([]runFile{missing}, SortFlags{}, dir); err == nil {
+		t.Error("mergeToRun() с отсутствующей серией не вернула ошибку")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("mergeToRun(): не удалено временных файлов: %d", len(entries))
+	}
+}
+
+// Тестируем разбор значения флага -S
+func TestSizeValue(t *testing.T) {
+	var v sizeValue
+	tests := map[string]int64{
+		"100b": 100,
+		"10":   10 << 10,
+		"10K":  10 << 10,
+		"2m":   2 << 20,
+		"1G":   1 << 30,
+		"1T":   1 << 40,
+	}
+	for input, expected := range tests {
+		if err := v.Set(input); err != nil || int64(v) != expected {
+			t.Errorf("Set(%q) = %d, %v; want %d", input, int64(v), err, expected)
+		}
+	}
+
+	for _, input := range []string{"", "K", "0", "-1M", "1X", "100000000T"} {
+		if err := v.Set(input); err == nil {
+			t.Errorf("Set(%q): ожидалась ошибка", input)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// openInputs открывает входные файлы; без аргументов и для имени "-"
+// используется стандартный ввод
+func openInputs(names []string) ([]io.ReadCloser, error) {
+	if len(names) == 0 {
+		names = []string{"-"}
+	}
+
+	var inputs []io.ReadCloser
+	for _, name := range names {
+		if name == "-" {
+			inputs = append(inputs, io.NopCloser(os.Stdin))
+			continue
+		}
+		f, err := os.Open(name)
+		if err != nil {
+			closeInputs(inputs)
+			return nil, err
+		}
+		inputs = append(inputs, f)
+	}
+	return inputs, nil
+}
+
+// closeInputs закрывает входные файлы
+func closeInputs(inputs []io.ReadCloser) {
+	for _, in := range inputs {
+		in.Close()
+	}
+}
+
+// outputFile — файл результата флага -o. Данные пишутся во временный файл в
+// том же каталоге и переносятся на место целевого только после успешной
+// записи, поэтому целевой файл может совпадать с входным.
+type outputFile struct {
+	*os.File
+	path string
+}
+
+// createOutput создает временный файл для результата, который будет
+// записан в path; права доступа наследуются от существующего файла, а
+// новый файл получает права 0666 с учетом umask, как при обычном создании
+func createOutput(path string) (*outputFile, error) {
+	f, err := createTemp(filepath.Dir(path), "."+filepath.Base(path)+".sort-")
+	if err != nil {
+		return nil, err
+	}
+
+	if info, err := os.Stat(path); err == nil {
+		if err := f.Chmod(info.Mode().Perm()); err != nil {
+			f.Close()
+			os.Remove(f.Name())
+			return nil, err
+		}
+	}
+	return &outputFile{File: f, path: path}, nil
+}
+
+// createTemp создает в dir новый файл с уникальным именем, начинающимся с
+// prefix. В отличие от os.CreateTemp, создающего файл с правами 0600, права
+// задаются как 0666 и ограничиваются umask.
+func createTemp(dir, prefix string) (*os.File, error) {
+	for try := 0; ; try++ {
+		name := filepath.Join(dir, prefix+strconv.FormatUint(uint64(rand.Uint32()), 10))
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o666)
+		if os.IsExist(err) && try < 10000 {
+			continue
+		}
+		return f, err
+	}
+}
+
+// Commit закрывает временный файл и переименовывает его в целевой
+func (o *outputFile) Commit() error {
+	if err := o.Close(); err != nil {
+		os.Remove(o.Name())
+		return err
+	}
+	if err := os.Rename(o.Name(), o.path); err != nil {
+		os.Remove(o.Name())
+		return err
+	}
+	return nil
+}
+
+// Abort закрывает и удаляет временный файл, не трогая целевой
+func (o *outputFile) Abort() {
+	o.Close()
+	os.Remove(o.Name())
+}
+
+// run сортирует или сливает входные файлы files и пишет результат в файл
+// sf.output либо, если он не задан, в stdout
+func run(sf SortFlags, files []string, stdout io.Writer) error {
+	checking := sf.check || sf.quietCheck
+	if checking && len(files) > 1 {
+		return fmt.Errorf("лишний операнд %q: при проверке допускается только один файл", files[1])
+	}
+
+	inputs, err := openInputs(files)
+	if err != nil {
+		return err
+	}
+	defer closeInputs(inputs)
+
+	readers := make([]io.Reader, len(inputs))
+	for i, in := range inputs {
+		readers[i] = in
+	}
+
+	if checking {
+		name := "-"
+		if len(files) == 1 {
+			name = files[0]
+		}
+		return checkInput(readers[0], name, sf)
+	}
+
+	w := stdout
+	var out *outputFile
+	if sf.output != "" {
+		if out, err = createOutput(sf.output); err != nil {
+			return err
+		}
+		w = out
+	}
+
+	if err := writeSorted(w, readers, sf); err != nil {
+		if out != nil {
+			out.Abort()
+		}
+		return err
+	}
+	if out != nil {
+		return out.Commit()
+	}
+	return nil
+}
+
+// writeSorted выбирает способ сортировки по флагам и пишет результат в w
+func writeSorted(w io.Writer, readers []io.Reader, sf SortFlags) error {
+	switch {
+	case sf.merge:
+		return mergeInputs(w, readers, sf)
+	case sf.bufferSize > 0:
+		return externalSort(readers, w, sf, int64(sf.bufferSize), sf.tmpDir)
+	}
+
+	lines, err := readInput(readers, sf)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, line := range sortLines(lines, sf) {
+		writeRecord(bw, line, sf)
+	}
+	return bw.Flush()
+}
+
+// mergeInputs сливает уже отсортированные входные данные без пересортировки
+func mergeInputs(w io.Writer, readers []io.Reader, sf SortFlags) error {
+	sources := make([]*bufio.Scanner, len(readers))
+	for i, r := range readers {
+		sources[i] = newRecordScanner(r, sf)
+	}
+
+	bw := bufio.NewWriter(w)
+	err := mergeReaders(sources, sf, sf.unique, func(line string) error {
+		return writeRecord(bw, line, sf)
+	})
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles создает во временном каталоге файлы с заданным содержимым
+func writeFiles(t *testing.T, contents ...string) []string {
+	t.Helper()
+	dir := t.TempDir()
+	var names []string
+	for i, content := range contents {
+		name := filepath.Join(dir, string(rune('a'+i))+".txt")
+		if err := os.WriteFile(name, []byte(content), 0o640); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// Тестируем сортировку нескольких файлов и слияние с -m
+func TestRunFiles(t *testing.T) {
+	// Последняя строка второго файла не заканчивается переводом строки
+	names := writeFiles(t, "apple\ncherry\nfig\n", "banana\ncherry\negg", "date\n")
+
+	tests := []struct {
+		name     string
+		sf       SortFlags
+		expected string
+	}{
+		{"сортировка", SortFlags{}, "apple\nbanana\ncherry\ncherry\ndate\negg\nfig\n"},
+		{"-m", SortFlags{merge: true}, "apple\nbanana\ncherry\ncherry\ndate\negg\nfig\n"},
+		{"-m -u", SortFlags{merge: true, unique: true}, "apple\nbanana\ncherry\ndate\negg\nfig\n"},
+		{"-S", SortFlags{bufferSize: 32, tmpDir: t.TempDir()}, "apple\nbanana\ncherry\ncherry\ndate\negg\nfig\n"},
+	}
+
+	for _, tt := range tests {
+		var out strings.Builder
+		if err := run(tt.sf, names, &out); err != nil {
+			t.Fatalf("%s: run() вернула ошибку: %v", tt.name, err)
+		}
+		if out.String() != tt.expected {
+			t.Errorf("%s: run() = %q; want %q", tt.name, out.String(), tt.expected)
+		}
+	}
+
+	// Слияние не пересортировывает входные данные
+	names = writeFiles(t, "b\na\n", "c\n")
+	var out strings.Builder
+	if err := run(SortFlags{merge: true}, names, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "b\na\nc\n" {
+		t.Errorf("run() с -m = %q; want %q", out.String(), "b\na\nc\n")
+	}
+}
+
+// Тестируем вывод в файл, совпадающий с входным
+func TestRunOutputInPlace(t *testing.T) {
+	names := writeFiles(t, "c\na\nb\n")
+	sf := SortFlags{output: names[0]}
+
+	var out strings.Builder
+	if err := run(sf, names, &out); err != nil {
+		t.Fatalf("run() вернула ошибку: %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("С -o стандартный вывод должен быть пуст, получено %q", out.String())
+	}
+
+	data, err := os.ReadFile(names[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "a\nb\nc\n" {
+		t.Errorf("Содержимое файла = %q; want %q", data, "a\nb\nc\n")
+	}
+
+	info, err := os.Stat(names[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o640 {
+		t.Errorf("Права файла = %v; want %v", info.Mode().Perm(), os.FileMode(0o640))
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(names[0]))
+	if len(entries) != 1 {
+		t.Errorf("В каталоге остались временные файлы: %d записей", len(entries))
+	}
+}
+
+// Тестируем права нового файла результата: 0666 с учетом umask
+func TestRunOutputNewFileMode(t *testing.T) {
+	names := writeFiles(t, "b\na\n")
+	dir := t.TempDir()
+	output := filepath.Join(dir, "out.txt")
+
+	// Файл, созданный обычным способом, получает права 0666 с учетом umask
+	probe := filepath.Join(dir, "probe")
+	if err := os.WriteFile(probe, nil, 0o666); err != nil {
+		t.Fatal(err)
+	}
+	probeInfo, err := os.Stat(probe)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := run(SortFlags{output: output}, names, io.Discard); err != nil {
+		t.Fatalf("run() вернула ошибку: %v", err)
+	}
+	info, err := os.Stat(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != probeInfo.Mode().Perm() {
+		t.Errorf("Права файла = %v; want %v", info.Mode().Perm(), probeInfo.Mode().Perm())
+	}
+}
+
+// Тестируем, что при ошибке чтения файл результата не создается
+func TestRunMissingInput(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "out.txt")
+	sf := SortFlags{output: output}
+
+	var out strings.Builder
+	if err := run(sf, []string{filepath.Join(dir, "missing.txt")}, &out); err == nil {
+		t.Fatal("Ожидалась ошибка для несуществующего файла")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("В каталоге появились файлы: %d записей", len(entries))
+	}
+}
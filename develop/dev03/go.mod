@@ -1,3 +1,5 @@
 module dev03
 
 go 1.22.2
+
+require golang.org/x/text v0.15.0
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// KeyOptions содержит параметры сравнения ключа
+type KeyOptions struct {
+	numeric      bool
+	reverse      bool
+	month        bool
+	human        bool
+	version      bool
+	ignoreBlanks bool
+	foldCase     bool
+	dictionary   bool
+	random       bool
+}
+
+// isZero сообщает, что параметры не заданы
+func (o KeyOptions) isZero() bool {
+	return o == KeyOptions{}
+}
+
+// KeyDef описывает ключ сортировки в формате POSIX: -k F1[.C1][опции][,F2[.C2][опции]]
+type KeyDef struct {
+	startField int // номер поля начала ключа, с единицы
+	startChar  int // номер символа в поле начала ключа, с единицы
+	endField   int // номер поля конца ключа; 0 — до конца строки
+	endChar    int // номер последнего символа в поле конца ключа; 0 — до конца поля
+	opts       KeyOptions
+}
+
+// parseKey разбирает определение ключа, например "2,3", "1.3,1.5" или "2n"
+func parseKey(s string) (KeyDef, error) {
+	var k KeyDef
+	start, end, hasEnd := strings.Cut(s, ",")
+
+	var err error
+	k.startField, k.startChar, err = parsePos(start, &k.opts)
+	if err != nil {
+		return k, fmt.Errorf("некорректный ключ %q: %w", s, err)
+	}
+	if k.startField == 0 {
+		return k, fmt.Errorf("некорректный ключ %q: номер поля должен быть больше нуля", s)
+	}
+	if k.startChar == 0 {
+		k.startChar = 1
+	}
+
+	if hasEnd {
+		k.endField, k.endChar, err = parsePos(end, &k.opts)
+		if err != nil {
+			return k, fmt.Errorf("некорректный ключ %q: %w", s, err)
+		}
+		if k.endField == 0 {
+			return k, fmt.Errorf("некорректный ключ %q: номер поля должен быть больше нуля", s)
+		}
+	}
+
+	return k, nil
+}
+
+// parsePos разбирает позицию F[.C][опции] и добавляет опции в opts
+func parsePos(s string, opts *KeyOptions) (field, char int, err error) {
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i < 0 {
+		i = len(s)
+	}
+	pos, flags := s[:i], s[i:]
+
+	fieldStr, charStr, hasChar := strings.Cut(pos, ".")
+	if field, err = strconv.Atoi(fieldStr); err != nil {
+		return 0, 0, fmt.Errorf("некорректный номер поля %q", fieldStr)
+	}
+	if hasChar {
+		if char, err = strconv.Atoi(charStr); err != nil {
+			return 0, 0, fmt.Errorf("некорректный номер символа %q", charStr)
+		}
+	}
+
+	for _, f := range flags {
+		switch f {
+		case 'n':
+			opts.numeric = true
+		case 'r':
+			opts.reverse = true
+		case 'M':
+			opts.month = true
+		case 'h':
+			opts.human = true
+		case 'V':
+			opts.version = true
+		case 'b':
+			opts.ignoreBlanks = true
+		case 'f':
+			opts.foldCase = true
+		case 'd':
+			opts.dictionary = true
+		case 'R':
+			opts.random = true
+		default:
+			return 0, 0, fmt.Errorf("неизвестная опция ключа %q", f)
+		}
+	}
+
+	return field, char, nil
+}
+
+// keyList — список ключей, задаваемых повторяющимся флагом -k
+type keyList []KeyDef
+
+// String реализует flag.Value
+func (l *keyList) String() string {
+	return fmt.Sprint(*l)
+}
+
+// Set реализует flag.Value
+func (l *keyList) Set(s string) error {
+	k, err := parseKey(s)
+	if err != nil {
+		return err
+	}
+	*l = append(*l, k)
+	return nil
+}
+
+// isBlank проверяет, является ли байт пробельным символом
+func isBlank(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+// field — границы поля в строке [start, end)
+type field struct {
+	start, end int
+}
+
+// splitFields возвращает границы полей строки. Если разделитель sep задан,
+// поля разделяются каждым его вхождением и могут быть пустыми. Без
+// разделителя поле состоит из пробелов, предшествующих ему, и последующих
+// непробельных символов.
+func splitFields(line string, sep rune) []field {
+	var fields []field
+	if sep != 0 {
+		start := 0
+		for {
+			i := strings.IndexRune(line[start:], sep)
+			if i < 0 {
+				return append(fields, field{start, len(line)})
+			}
+			fields = append(fields, field{start, start + i})
+			start += i + utf8.RuneLen(sep)
+		}
+	}
+
+	start := 0
+	for i := 1; i < len(line); i++ {
+		if isBlank(line[i]) && !isBlank(line[i-1]) {
+			fields = append(fields, field{start, i})
+			start = i
+		}
+	}
+	return append(fields, field{start, len(line)})
+}
+
+// skipBlanks пропускает пробелы начиная со смещения pos, но не дальше end
+func skipBlanks(line string, pos, end int) int {
+	for pos < end && isBlank(line[pos]) {
+		pos++
+	}
+	return pos
+}
+
+// skipChars пропускает n символов UTF-8 начиная со смещения pos, но не дальше end
+func skipChars(line string, pos, end, n int) int {
+	for ; n > 0 && pos < end; n-- {
+		_, size := utf8.DecodeRuneInString(line[pos:end])
+		pos += size
+	}
+	return pos
+}
+
+// extract возвращает часть строки, соответствующую ключу
+func (k KeyDef) extract(line string, sep rune, opts KeyOptions) string {
+	fields := splitFields(line, sep)
+
+	if k.startField > len(fields) {
+		return ""
+	}
+	f := fields[k.startField-1]
+	begin := f.start
+	if opts.ignoreBlanks {
+		begin = skipBlanks(line, begin, f.end)
+	}
+	begin = skipChars(line, begin, f.end, k.startChar-1)
+
+	end := len(line)
+	if k.endField > 0 && k.endField <= len(fields) {
+		f = fields[k.endField-1]
+		end = f.end
+		if k.endChar > 0 {
+			pos := f.start
+			if opts.ignoreBlanks {
+				pos = skipBlanks(line, pos, f.end)
+			}
+			end = skipChars(line, pos, f.end, k.endChar)
+		}
+	}
+
+	if end < begin {
+		return ""
+	}
+	return line[begin:end]
+}
+
+// separatorValue — значение флага -t: ровно один символ
+type separatorValue rune
+
+// String реализует flag.Value
+func (v *separatorValue) String() string {
+	if *v == 0 {
+		return ""
+	}
+	return string(*v)
+}
+
+// Set реализует flag.Value; "\\t" означает символ табуляции
+func (v *separatorValue) Set(s string) error {
+	if s == `\t` {
+		s = "\t"
+	}
+	if utf8.RuneCountInString(s) != 1 {
+		return fmt.Errorf("разделитель должен состоять из одного символа: %q", s)
+	}
+	r, _ := utf8.DecodeRuneInString(s)
+	*v = separatorValue(r)
+	return nil
+}
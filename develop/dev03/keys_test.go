@@ -0,0 +1,201 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// Тестируем разбор определений ключей
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected KeyDef
+	}{
+		{"2", KeyDef{startField: 2, startChar: 1}},
+		{"2,3", KeyDef{startField: 2, startChar: 1, endField: 3}},
+		{"1.3,1.5", KeyDef{startField: 1, startChar: 3, endField: 1, endChar: 5}},
+		{"2n", KeyDef{startField: 2, startChar: 1, opts: KeyOptions{numeric: true}}},
+		{"1r,1", KeyDef{startField: 1, startChar: 1, endField: 1, opts: KeyOptions{reverse: true}}},
+		{"3.2b,3hM", KeyDef{startField: 3, startChar: 2, endField: 3, opts: KeyOptions{ignoreBlanks: true, human: true, month: true}}},
+	}
+
+	for _, tt := range tests {
+		got, err := parseKey(tt.input)
+		if err != nil {
+			t.Fatalf("parseKey(%q) вернула ошибку: %v", tt.input, err)
+		}
+		if got != tt.expected {
+			t.Errorf("parseKey(%q) = %+v; want %+v", tt.input, got, tt.expected)
+		}
+	}
+
+	for _, input := range []string{"", "0", "a", "1,0", "1.x", "2z", "1,"} {
+		if _, err := parseKey(input); err == nil {
+			t.Errorf("parseKey(%q): ожидалась ошибка", input)
+		}
+	}
+}
+
+// Тестируем извлечение ключа из строки
+func TestKeyExtract(t *testing.T) {
+	line := "alpha  beta gamma\tdelta"
+	tests := []struct {
+		key      string
+		expected string
+	}{
+		{"1", "alpha  beta gamma\tdelta"},
+		{"2", "  beta gamma\tdelta"},
+		{"2,2", "  beta"},
+		{"2b,2", "beta"},
+		{"2.2b,2.3", "et"},
+		{"1.2,1.4", "lph"},
+		{"3,4", " gamma\tdelta"},
+		{"4", "\tdelta"},
+		{"5", ""},
+		{"1.10,1", ""},
+	}
+
+	for _, tt := range tests {
+		k, err := parseKey(tt.key)
+		if err != nil {
+			t.Fatalf("parseKey(%q) вернула ошибку: %v", tt.key, err)
+		}
+		if got := k.extract(line, 0, k.opts); got != tt.expected {
+			t.Errorf("ключ %s: extract() = %q; want %q", tt.key, got, tt.expected)
+		}
+	}
+
+	k, _ := parseKey("1.2,1.3")
+	if got := k.extract("ёжик", 0, k.opts); got != "жи" {
+		t.Errorf("extract() для кириллицы = %q; want %q", got, "жи")
+	}
+}
+
+// Тестируем сортировку по нескольким ключам
+func TestSortLinesKeys(t *testing.T) {
+	var keys keyList
+	for _, k := range []string{"2n", "1r"} {
+		if err := keys.Set(k); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lines := []string{"b 10", "a 2", "c 10", "d 2", "e 1"}
+	expected := []string{"e 1", "d 2", "a 2", "c 10", "b 10"}
+	got := sortLines(lines, SortFlags{keys: keys})
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("sortLines() = %v; want %v", got, expected)
+	}
+
+	// Ключ без опций наследует глобальные
+	keys = nil
+	keys.Set("2,2")
+	lines = []string{"x 10 b", "y 9 a", "z 100 c"}
+	expected = []string{"z 100 c", "x 10 b", "y 9 a"}
+	got = sortLines(lines, SortFlags{keys: keys, numeric: true, reverse: true})
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("sortLines() = %v; want %v", got, expected)
+	}
+}
+
+// Тестируем приведение флагов к формату пакета flag
+func TestNormalizeArgs(t *testing.T) {
+	fs := flag.NewFlagSet("sort", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var keys keyList
+	fs.Var(&keys, "k", "")
+	fs.Bool("n", false, "")
+	fs.Bool("r", false, "")
+
+	args := normalizeArgs(fs, []string{"-k2,3", "-nr", "-k1.3n", "-n", "-x1", "--", "-nr"})
+	expected := []string{"-k", "2,3", "-n", "-r", "-k", "1.3n", "-n", "-x1", "--", "-nr"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Fatalf("normalizeArgs() = %v; want %v", args, expected)
+	}
+
+	if err := fs.Parse(args); err == nil {
+		t.Fatalf("Ожидалась ошибка для неизвестного флага -x1")
+	}
+}
+
+// Тестируем разбиение на поля по разделителю
+func TestSplitFieldsSeparator(t *testing.T) {
+	line := "root::0:0:root:/root:/bin/bash"
+	var got []string
+	for _, f := range splitFields(line, ':') {
+		got = append(got, line[f.start:f.end])
+	}
+	expected := []string{"root", "", "0", "0", "root", "/root", "/bin/bash"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("splitFields() = %q; want %q", got, expected)
+	}
+
+	got = nil
+	line = "а;;б;"
+	for _, f := range splitFields(line, ';') {
+		got = append(got, line[f.start:f.end])
+	}
+	expected = []string{"а", "", "б", ""}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("splitFields() = %q; want %q", got, expected)
+	}
+}
+
+// Тестируем сортировку по полям с разделителем и уникальность по ключу
+func TestSortLinesSeparator(t *testing.T) {
+	var keys keyList
+	keys.Set("3,3n")
+
+	lines := []string{
+		"user:x:1000:1000::/home/user:/bin/bash",
+		"root:x:0:0:root:/root:/bin/bash",
+		"daemon:x:1:1:daemon:/usr/sbin:/usr/sbin/nologin",
+		"nobody:x:65534:65534:nobody:/nonexistent:/usr/sbin/nologin",
+		"toor:x:0:0:root:/root:/bin/sh",
+	}
+	expected := []string{
+		"root:x:0:0:root:/root:/bin/bash",
+		"toor:x:0:0:root:/root:/bin/sh",
+		"daemon:x:1:1:daemon:/usr/sbin:/usr/sbin/nologin",
+		"user:x:1000:1000::/home/user:/bin/bash",
+		"nobody:x:65534:65534:nobody:/nonexistent:/usr/sbin/nologin",
+	}
+	sf := SortFlags{keys: keys, separator: ':'}
+	got := sortLines(append([]string(nil), lines...), sf)
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("sortLines() = %v; want %v", got, expected)
+	}
+
+	sf.unique = true
+	got = sortLines(append([]string(nil), lines...), sf)
+	expected = append(expected[:1], expected[2:]...)
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("sortLines() с -u = %v; want %v", got, expected)
+	}
+
+	// Пустые поля сохраняются: у строки "b," второе поле пусто
+	keys = nil
+	keys.Set("2,2")
+	got = sortLines([]string{"a,b", "b,", "c,a"}, SortFlags{keys: keys, separator: ','})
+	expected = []string{"b,", "c,a", "a,b"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("sortLines() с пустым полем = %v; want %v", got, expected)
+	}
+}
+
+// Тестируем разбор значения флага -t
+func TestSeparatorValue(t *testing.T) {
+	var v separatorValue
+	for input, expected := range map[string]rune{":": ':', `\t`: '\t', "ж": 'ж'} {
+		if err := v.Set(input); err != nil || rune(v) != expected {
+			t.Errorf("Set(%q) = %q, %v; want %q", input, rune(v), err, expected)
+		}
+	}
+	for _, input := range []string{"", "::"} {
+		if err := v.Set(input); err == nil {
+			t.Errorf("Set(%q): ожидалась ошибка", input)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// minMonthPrefix — минимальная длина сокращения названия месяца в символах
+const minMonthPrefix = 3
+
+// monthNames содержит формы названий месяцев по порядку: английские и
+// русские в именительном и родительном падежах
+var monthNames = [12][]string{
+	{"january", "январь", "января"},
+	{"february", "февраль", "февраля"},
+	{"march", "март", "марта"},
+	{"april", "апрель", "апреля"},
+	{"may", "май", "мая"},
+	{"june", "июнь", "июня"},
+	{"july", "июль", "июля"},
+	{"august", "август", "августа"},
+	{"september", "сентябрь", "сентября"},
+	{"october", "октябрь", "октября"},
+	{"november", "ноябрь", "ноября"},
+	{"december", "декабрь", "декабря"},
+}
+
+// monthNumber возвращает номер месяца (1–12), название которого стоит в
+// начале строки после пробелов. Регистр не учитывается; подходит полное
+// название или его начало не короче трех букв: "Jan", "SEPT", "фев",
+// "мая". Для строк без названия месяца возвращается 0, поэтому при -M они
+// идут перед январем, как в GNU sort.
+func monthNumber(s string) int {
+	s = strings.TrimLeft(s, " \t")
+	end := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsLetter(r) })
+	if end < 0 {
+		end = len(s)
+	}
+	word := strings.ToLower(s[:end])
+	if utf8.RuneCountInString(word) < minMonthPrefix {
+		return 0
+	}
+
+	for i, forms := range monthNames {
+		for _, form := range forms {
+			if strings.HasPrefix(form, word) {
+				return i + 1
+			}
+		}
+	}
+	return 0
+}
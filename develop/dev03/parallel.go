@@ -0,0 +1,91 @@
+package main
+
+import (
+	"sort"
+	"sync"
+)
+
+// minParallelChunk — минимальное число строк на одну горутину; меньшие
+// порции быстрее отсортировать последовательно
+const minParallelChunk = 1024
+
+// stableSort устойчиво сортирует строки; при workers > 1 сортировка
+// выполняется параллельно
+func stableSort(lines []string, workers int, less func(a, b string) bool) {
+	workers = min(workers, len(lines)/minParallelChunk)
+	if workers <= 1 {
+		sort.SliceStable(lines, func(i, j int) bool {
+			return less(lines[i], lines[j])
+		})
+		return
+	}
+	parallelSort(lines, workers, less)
+}
+
+// parallelSort делит строки на workers смежных порций, сортирует их
+// конкурентно и попарно сливает соседние порции. При слиянии из равных строк
+// первой берется строка левой порции, поэтому результат устойчив и совпадает
+// с последовательной сортировкой.
+func parallelSort(lines []string, workers int, less func(a, b string) bool) {
+	bounds := make([]int, workers+1)
+	for i := range bounds {
+		bounds[i] = i * len(lines) / workers
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(chunk []string) {
+			defer wg.Done()
+			sort.SliceStable(chunk, func(i, j int) bool {
+				return less(chunk[i], chunk[j])
+			})
+		}(lines[bounds[i]:bounds[i+1]])
+	}
+	wg.Wait()
+
+	src, dst := lines, make([]string, len(lines))
+	for len(bounds) > 2 {
+		var next []int
+		for i := 0; i+1 < len(bounds); i += 2 {
+			lo := bounds[i]
+			next = append(next, lo)
+			if i+2 >= len(bounds) {
+				// Непарная последняя порция переносится без слияния
+				copy(dst[lo:], src[lo:bounds[i+1]])
+				continue
+			}
+			mid, hi := bounds[i+1], bounds[i+2]
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				mergeSorted(dst[lo:hi], src[lo:mid], src[mid:hi], less)
+			}()
+		}
+		wg.Wait()
+		bounds = append(next, len(lines))
+		src, dst = dst, src
+	}
+
+	if &src[0] != &lines[0] {
+		copy(lines, src)
+	}
+}
+
+// mergeSorted сливает отсортированные a и b в dst; при равенстве первой
+// идет строка из a
+func mergeSorted(dst, a, b []string, less func(a, b string) bool) {
+	i, j, k := 0, 0, 0
+	for i < len(a) && j < len(b) {
+		if less(b[j], a[i]) {
+			dst[k] = b[j]
+			j++
+		} else {
+			dst[k] = a[i]
+			i++
+		}
+		k++
+	}
+	k += copy(dst[k:], a[i:])
+	copy(dst[k:], b[j:])
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// syntheticLines генерирует n случайных строк вида "слово число"
+func syntheticLines(n int) []string {
+	rng := rand.New(rand.NewSource(int64(n)))
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("%c%c%d %d", 'a'+rng.Intn(26), 'a'+rng.Intn(26), rng.Intn(1000), rng.Intn(100000))
+	}
+	return lines
+}
+
+// Тестируем устойчивость и совпадение параллельной сортировки с последовательной
+func TestParallelSort(t *testing.T) {
+	// Сравнение только по первому символу дает много равных строк, на
+	// которых проверяется устойчивость
+	byFirst := func(a, b string) bool { return a[0] < b[0] }
+
+	for _, n := range []int{0, 1, 100, 5000, 20011} {
+		lines := syntheticLines(n)
+		expected := append([]string(nil), lines...)
+		sort.SliceStable(expected, func(i, j int) bool {
+			return byFirst(expected[i], expected[j])
+		})
+
+		for _, workers := range []int{1, 2, 3, 4, 7, 16} {
+			got := append([]string(nil), lines...)
+			stableSort(got, workers, byFirst)
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("n = %d, workers = %d: результат отличается от последовательной сортировки", n, workers)
+			}
+		}
+	}
+
+	var keys keyList
+	keys.Set("2n")
+	lines := syntheticLines(30000)
+	for _, sf := range []SortFlags{{}, {reverse: true}, {keys: keys, unique: true}} {
+		expected := sortLines(append([]string(nil), lines...), sf)
+		sf.parallel = 8
+		got := sortLines(append([]string(nil), lines...), sf)
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("sortLines() с %+v: результат отличается от последовательной сортировки", sf)
+		}
+	}
+}
+
+// benchmarkSortLines измеряет сортировку n строк в workers горутинах
+func benchmarkSortLines(b *testing.B, n, workers int) {
+	lines := syntheticLines(n)
+	sf := SortFlags{parallel: workers}
+	buf := make([]string, n)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		copy(buf, lines)
+		sortLines(buf, sf)
+	}
+}
+
+func BenchmarkSortLinesSequential(b *testing.B) { benchmarkSortLines(b, 200000, 1) }
+func BenchmarkSortLinesParallel2(b *testing.B)  { benchmarkSortLines(b, 200000, 2) }
+func BenchmarkSortLinesParallel4(b *testing.B)  { benchmarkSortLines(b, 200000, 4) }
+func BenchmarkSortLinesParallel8(b *testing.B)  { benchmarkSortLines(b, 200000, 8) }
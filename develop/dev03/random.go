@@ -0,0 +1,74 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+)
+
+// randomSaltSize — число байт, которые берутся из источника случайности
+const randomSaltSize = 8
+
+// newRandomSalt возвращает случайную соль для флага -R
+func newRandomSalt() uint64 {
+	var b [randomSaltSize]byte
+	rand.Read(b[:])
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+// readRandomSource читает соль для флага -R из файла, как --random-source
+// в GNU sort: одинаковый файл дает одинаковый порядок
+func readRandomSource(name string) (uint64, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var b [randomSaltSize]byte
+	if _, err := io.ReadFull(f, b[:]); err != nil {
+		return 0, fmt.Errorf("недостаточно данных в источнике случайности %q: %w", name, err)
+	}
+	return binary.LittleEndian.Uint64(b[:]), nil
+}
+
+// randomHash вычисляет хеш ключа с солью: FNV-1a с перемешиванием
+// результата, чтобы порядок хешей не зависел от общего префикса ключей
+func randomHash(s string, salt uint64) uint64 {
+	const (
+		offset = 14695981039346656037
+		prime  = 1099511628211
+	)
+
+	h := uint64(offset)
+	for i := 0; i < randomSaltSize; i++ {
+		h ^= (salt >> (8 * i)) & 0xff
+		h *= prime
+	}
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= prime
+	}
+
+	h ^= h >> 33
+	h *= 0xff51afec4fd7ed55
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+// compareRandom сравнивает ключи по случайному хешу, как -R в GNU sort:
+// порядок случаен, но равные ключи получают равный хеш и идут рядом
+func compareRandom(a, b string, salt uint64) int {
+	ha, hb := randomHash(a, salt), randomHash(b, salt)
+	switch {
+	case ha < hb:
+		return -1
+	case ha > hb:
+		return 1
+	}
+	return 0
+}
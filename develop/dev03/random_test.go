@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Тестируем случайный порядок с группировкой равных ключей
+func TestSortLinesRandom(t *testing.T) {
+	var lines []string
+	for _, s := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		lines = append(lines, s, strings.ToUpper(s), s)
+	}
+
+	// Равные строки идут рядом, порядок воспроизводим при той же соли
+	sf := SortFlags{random: true, randomSalt: 42}
+	got := sortLines(append([]string(nil), lines...), sf)
+	if !reflect.DeepEqual(got, sortLines(append([]string(nil), lines...), sf)) {
+		t.Errorf("sortLines() с одной солью дает разный порядок")
+	}
+	checkGrouped(t, got, func(s string) string { return s })
+
+	orders := map[string]bool{}
+	for salt := uint64(0); salt < 10; salt++ {
+		sf.randomSalt = salt
+		orders[strings.Join(sortLines(append([]string(nil), lines...), sf), ",")] = true
+	}
+	if len(orders) < 2 {
+		t.Errorf("sortLines() с -R не зависит от соли")
+	}
+
+	// С -f группируются строки, различающиеся только регистром
+	sf = SortFlags{random: true, foldCase: true, randomSalt: 7}
+	checkGrouped(t, sortLines(append([]string(nil), lines...), sf), strings.ToUpper)
+
+	// Ключ с опцией R группирует строки по полю
+	var keys keyList
+	keys.Set("2R")
+	lines = []string{"1 x", "2 y", "3 x", "4 z", "5 y", "6 x"}
+	got = sortLines(append([]string(nil), lines...), SortFlags{keys: keys, randomSalt: 3})
+	checkGrouped(t, got, func(s string) string { return s[2:] })
+}
+
+// checkGrouped проверяет, что строки с равным group(s) идут подряд
+func checkGrouped(t *testing.T, lines []string, group func(string) string) {
+	t.Helper()
+	seen := map[string]bool{}
+	for i, line := range lines {
+		g := group(line)
+		if i > 0 && group(lines[i-1]) == g {
+			continue
+		}
+		if seen[g] {
+			t.Errorf("Строки группы %q идут не подряд: %q", g, lines)
+			return
+		}
+		seen[g] = true
+	}
+}
+
+// Тестируем чтение соли из источника случайности
+func TestReadRandomSource(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "random")
+	os.WriteFile(name, []byte("0123456789"), 0o600)
+
+	a, err := readRandomSource(name)
+	if err != nil {
+		t.Fatalf("readRandomSource() вернула ошибку: %v", err)
+	}
+	b, _ := readRandomSource(name)
+	if a != b {
+		t.Errorf("readRandomSource() для одного файла = %d и %d", a, b)
+	}
+
+	os.WriteFile(name, []byte("short"), 0o600)
+	if _, err := readRandomSource(name); err == nil {
+		t.Error("readRandomSource(): ожидалась ошибка для короткого файла")
+	}
+}
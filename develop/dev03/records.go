@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"math"
+)
+
+// initialRecordBuffer — начальный размер буфера чтения записей; буфер
+// растет по мере необходимости, длина записи не ограничена
+const initialRecordBuffer = 64 * 1024
+
+// delimiter возвращает байт, завершающий запись: NUL с флагом -z, иначе
+// перевод строки
+func (sf SortFlags) delimiter() byte {
+	if sf.zeroTerminated {
+		return 0
+	}
+	return '\n'
+}
+
+// newRecordScanner возвращает сканер записей из r с учетом флагов -z и
+// -csv. Длина записи не ограничена.
+func newRecordScanner(r io.Reader, sf SortFlags) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, initialRecordBuffer), math.MaxInt)
+	switch {
+	case sf.zeroTerminated:
+		scanner.Split(scanZeroTerminated)
+	case sf.csv:
+		scanner.Split(scanCSVRecords)
+	}
+	return scanner
+}
+
+// writeRecord записывает запись и завершающий ее разделитель
+func writeRecord(w *bufio.Writer, record string, sf SortFlags) error {
+	w.WriteString(record)
+	return w.WriteByte(sf.delimiter())
+}
+
+// scanZeroTerminated — функция разбиения для bufio.Scanner, выделяющая
+// записи, завершенные байтом NUL
+func scanZeroTerminated(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
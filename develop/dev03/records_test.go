@@ -0,0 +1,136 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Тестируем записи, завершенные байтом NUL
+func TestRunZeroTerminated(t *testing.T) {
+	names := writeFiles(t, "file\nwith newline\x00b\x00a", "c\x00")
+	expected := "a\x00b\x00c\x00file\nwith newline\x00"
+
+	for _, sf := range []SortFlags{
+		{zeroTerminated: true},
+		{zeroTerminated: true, bufferSize: 8, tmpDir: t.TempDir()},
+	} {
+		var out strings.Builder
+		if err := run(sf, names, &out); err != nil {
+			t.Fatalf("run() вернула ошибку: %v", err)
+		}
+		if out.String() != expected {
+			t.Errorf("run() с %+v = %q; want %q", sf, out.String(), expected)
+		}
+	}
+
+	var out strings.Builder
+	names = writeFiles(t, "a\x00c\x00", "b\nb\x00")
+	if err := run(SortFlags{zeroTerminated: true, merge: true}, names, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "a\x00b\nb\x00c\x00" {
+		t.Errorf("run() с -z -m = %q; want %q", out.String(), "a\x00b\nb\x00c\x00")
+	}
+
+	err := run(SortFlags{zeroTerminated: true, check: true}, writeFiles(t, "a\x00c\x00b\x00"), nil)
+	if err == nil || !strings.HasSuffix(err.Error(), ":3: disorder: b") {
+		t.Errorf("run() с -z -c = %v; want нарушение в записи 3", err)
+	}
+}
+
+// Тестируем строки длиннее 64 KiB
+func TestRunLongLines(t *testing.T) {
+	long := strings.Repeat("x", 1<<20)
+	names := writeFiles(t, "b"+long+"\na"+long+"\n")
+
+	for _, sf := range []SortFlags{{}, {bufferSize: 1 << 10, tmpDir: t.TempDir()}} {
+		var out strings.Builder
+		if err := run(sf, names, &out); err != nil {
+			t.Fatalf("run() вернула ошибку: %v", err)
+		}
+		if out.String() != "a"+long+"\nb"+long+"\n" {
+			t.Errorf("run() с %+v: неверный результат для длинных строк", sf)
+		}
+	}
+}
+
+// Тестируем разбор полей CSV
+func TestCSVFields(t *testing.T) {
+	tests := []struct {
+		record   string
+		sep      rune
+		expected []string
+	}{
+		{"a,b,c", ',', []string{"a", "b", "c"}},
+		{"", ',', []string{""}},
+		{"a,,", ',', []string{"a", "", ""}},
+		{`"a,b",c`, ',', []string{"a,b", "c"}},
+		{`"say ""hi""",x`, ',', []string{`say "hi"`, "x"}},
+		{"\"line1\nline2\",z", ',', []string{"line1\nline2", "z"}},
+		{`"unterminated,x`, ',', []string{"unterminated,x"}},
+		{`"a"b,c`, ',', []string{"ab", "c"}},
+		{`a;"b;c";d`, ';', []string{"a", "b;c", "d"}},
+		{`x"y,z`, ',', []string{`x"y`, "z"}},
+	}
+
+	for _, tt := range tests {
+		if got := csvFields(tt.record, tt.sep); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("csvFields(%q) = %q; want %q", tt.record, got, tt.expected)
+		}
+	}
+}
+
+// Тестируем сортировку CSV по ключам
+func TestRunCSV(t *testing.T) {
+	input := "name,city,amount\r\n" +
+		"\"Smith, John\",\"New York\",100\r\n" +
+		"Brown,\"Paris,\nFrance\",20\r\n" +
+		"\"Adams\",\"\"\"Quoted\"\" City\",3\r\n"
+	names := writeFiles(t, input)
+
+	var keys keyList
+	keys.Set("3,3n")
+	sf := SortFlags{csv: true, keys: keys}
+
+	var out strings.Builder
+	if err := run(sf, names, &out); err != nil {
+		t.Fatalf("run() вернула ошибку: %v", err)
+	}
+	// Заголовок без числа считается нулем
+	expected := "name,city,amount\n" +
+		"\"Adams\",\"\"\"Quoted\"\" City\",3\n" +
+		"Brown,\"Paris,\nFrance\",20\n" +
+		"\"Smith, John\",\"New York\",100\n"
+	if out.String() != expected {
+		t.Errorf("run() с -csv -k3,3n = %q; want %q", out.String(), expected)
+	}
+
+	// Ключ по второму полю сравнивает значения без кавычек
+	keys = nil
+	keys.Set("2,2")
+	sf = SortFlags{csv: true, keys: keys, bufferSize: 16, tmpDir: t.TempDir()}
+	out.Reset()
+	if err := run(sf, names, &out); err != nil {
+		t.Fatalf("run() вернула ошибку: %v", err)
+	}
+	expected = "\"Adams\",\"\"\"Quoted\"\" City\",3\n" +
+		"\"Smith, John\",\"New York\",100\n" +
+		"Brown,\"Paris,\nFrance\",20\n" +
+		"name,city,amount\n"
+	if out.String() != expected {
+		t.Errorf("run() с -csv -k2,2 -S = %q; want %q", out.String(), expected)
+	}
+
+	// Разделитель задается флагом -t, ключ охватывает несколько полей
+	keys = nil
+	keys.Set("2.2,3.1")
+	sf = SortFlags{csv: true, keys: keys, separator: ';'}
+	lines := []string{`1;"xb;z";q`, `2;xa;"r;s"`}
+	if got := sf.extractKey(keys[0], lines[0], keys[0].opts); got != "b;z;q" {
+		t.Errorf("extractKey(%q) = %q; want %q", lines[0], got, "b;z;q")
+	}
+	if got := sortLines(append([]string(nil), lines...), sf); !reflect.DeepEqual(got, []string{lines[1], lines[0]}) {
+		t.Errorf("sortLines() с -csv -t';' = %q", got)
+	}
+}
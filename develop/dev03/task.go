@@ -26,130 +26,162 @@ package main
 */
 
 import (
-	"bufio"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
-	"sort"
 	"strconv"
 	"strings"
 )
 
 // SortFlags содержит флаги для сортировки
 type SortFlags struct {
-	column       int
-	numeric      bool
-	reverse      bool
-	unique       bool
-	month        bool
-	ignoreBlanks bool
-	check        bool
-	human        bool
+	keys           keyList
+	separator      separatorValue
+	numeric        bool
+	reverse        bool
+	unique         bool
+	stable         bool
+	month          bool
+	ignoreBlanks   bool
+	check          bool
+	quietCheck     bool
+	human          bool
+	version        bool
+	foldCase       bool
+	dictionary     bool
+	random         bool
+	randomSalt     uint64
+	collator       *collator
+	bufferSize     sizeValue
+	tmpDir         string
+	parallel       int
+	merge          bool
+	output         string
+	zeroTerminated bool
+	csv            bool
 }
 
 // parseFlags парсит флаги командной строки
 func parseFlags() SortFlags {
-	var sf SortFlags
+	sf := SortFlags{randomSalt: newRandomSalt()}
 
-	flag.IntVar(&sf.column, "k", 0, "указание колонки для сортировки")
+	flag.Var(&sf.keys, "k", "ключ сортировки F1[.C1][опции][,F2[.C2][опции]], опции: n, r, b, M, h, V, f, d, R; можно указать несколько")
+	flag.Var(&sf.separator, "t", "разделитель полей (один символ); по умолчанию поля разделяются пробелами")
 	flag.BoolVar(&sf.numeric, "n", false, "сортировать по числовому значению")
 	flag.BoolVar(&sf.reverse, "r", false, "сортировать в обратном порядке")
-	flag.BoolVar(&sf.unique, "u", false, "не выводить повторяющиеся строки")
+	flag.BoolVar(&sf.unique, "u", false, "не выводить строки с повторяющимися ключами")
+	flag.BoolVar(&sf.stable, "s", false, "устойчивая сортировка: не сравнивать строки целиком при равных ключах")
 	flag.BoolVar(&sf.month, "M", false, "сортировать по названию месяца")
 	flag.BoolVar(&sf.ignoreBlanks, "b", false, "игнорировать хвостовые пробелы")
-	flag.BoolVar(&sf.check, "c", false, "проверять отсортированы ли данные")
+	flag.BoolVar(&sf.check, "c", false, "проверить, отсортированы ли данные, и сообщить о первом нарушении порядка")
+	flag.BoolVar(&sf.quietCheck, "C", false, "как -c, но без сообщения о нарушении порядка")
 	flag.BoolVar(&sf.human, "h", false, "сортировать по числовому значению с учётом суффиксов")
+	flag.BoolVar(&sf.version, "V", false, "сортировать как номера версий: file2 < file10, 1.9 < 1.10")
+	flag.BoolVar(&sf.foldCase, "f", false, "не различать строчные и заглавные буквы")
+	flag.BoolVar(&sf.dictionary, "d", false, "учитывать только буквы, цифры и пробелы")
+	flag.BoolVar(&sf.random, "R", false, "случайный порядок, при котором строки с равными ключами идут рядом")
+	flag.Func("random-source", "файл, из которого берутся случайные данные для -R", func(s string) error {
+		salt, err := readRandomSource(s)
+		sf.randomSalt = salt
+		return err
+	})
+	flag.Func("random-seed", "число, задающее порядок -R вместо случайных данных", func(s string) error {
+		salt, err := strconv.ParseUint(s, 10, 64)
+		sf.randomSalt = salt
+		return err
+	})
+	flag.Func("locale", "сравнивать строки по правилам локали, например ru или en_US.UTF-8; C — побайтово", func(s string) error {
+		c, err := newCollator(s)
+		sf.collator = c
+		return err
+	})
+	flag.Var(&sf.bufferSize, "S", "размер буфера в памяти (суффиксы b, K, M, G, T); при переполнении используется внешняя сортировка")
+	flag.StringVar(&sf.tmpDir, "T", os.TempDir(), "каталог для временных файлов внешней сортировки")
+	flag.BoolVar(&sf.merge, "m", false, "слить уже отсортированные файлы без пересортировки")
+	flag.StringVar(&sf.output, "o", "", "записать результат в файл вместо стандартного вывода; файл может совпадать с входным")
+	flag.BoolVar(&sf.zeroTerminated, "z", false, "записи завершаются байтом NUL, а не переводом строки")
+	flag.BoolVar(&sf.csv, "csv", false, "разбирать записи как CSV (RFC 4180): поля в кавычках могут содержать разделитель и перевод строки; по умолчанию разделитель — запятая")
+	flag.IntVar(&sf.parallel, "parallel", 1, "число горутин для параллельной сортировки")
 
-	flag.Parse()
+	flag.CommandLine.Parse(normalizeArgs(flag.CommandLine, os.Args[1:]))
 
 	return sf
 }
 
-// readInput читает входные данные
-func readInput() ([]string, error) {
-	scanner := bufio.NewScanner(os.Stdin)
-	var lines []string
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
-	}
-	return lines, scanner.Err()
-}
-
-// humanNumericLess сравнивает строки с учетом суффиксов
-func humanNumericLess(a, b string) bool {
-	// Пример реализации функции
-	// Сортировка с учётом суффиксов не реализована для простоты примера
-	return a < b
-}
-
-// monthLess сравнивает строки как месяцы
-func monthLess(a, b string) bool {
-	months := map[string]int{
-		"Jan": 1, "Feb": 2, "Mar": 3, "Apr": 4,
-		"May": 5, "Jun": 6, "Jul": 7, "Aug": 8,
-		"Sep": 9, "Oct": 10, "Nov": 11, "Dec": 12,
-	}
-	return months[a] < months[b]
-}
-
-// checkSorted проверяет, отсортированы ли строки
-func checkSorted(lines []string, sf SortFlags) bool {
-	for i := 0; i < len(lines)-1; i++ {
-		if !less(lines[i], lines[i+1], sf) {
-			return false
+// normalizeArgs приводит короткие флаги в стиле POSIX к виду, понятному
+// пакету flag: "-k2,3" превращается в "-k 2,3", а "-nr" — в "-n -r"
+func normalizeArgs(fs *flag.FlagSet, args []string) []string {
+	var result []string
+	for i, arg := range args {
+		if arg == "--" {
+			return append(result, args[i:]...)
+		}
+		if len(arg) < 3 || arg[0] != '-' || arg[1] == '-' || strings.Contains(arg, "=") {
+			result = append(result, arg)
+			continue
+		}
+		if fs.Lookup(arg[1:]) != nil {
+			result = append(result, arg)
+			continue
 		}
-	}
-	return true
-}
-
-// less сравнивает две строки в зависимости от флагов
-func less(a, b string, sf SortFlags) bool {
-	if sf.ignoreBlanks {
-		a = strings.TrimSpace(a)
-		b = strings.TrimSpace(b)
-	}
 
-	if sf.column > 0 {
-		aFields := strings.Fields(a)
-		bFields := strings.Fields(b)
-		if sf.column-1 < len(aFields) && sf.column-1 < len(bFields) {
-			a = aFields[sf.column-1]
-			b = bFields[sf.column-1]
+		expanded, ok := expandShortFlags(fs, arg[1:])
+		if !ok {
+			expanded = []string{arg}
 		}
+		result = append(result, expanded...)
 	}
+	return result
+}
 
-	if sf.numeric {
-		aNum, aErr := strconv.ParseFloat(a, 64)
-		bNum, bErr := strconv.ParseFloat(b, 64)
-		if aErr == nil && bErr == nil {
-			return aNum < bNum
+// expandShortFlags разбивает группу коротких флагов; значение флага,
+// требующего аргумент, берется из остатка группы
+func expandShortFlags(fs *flag.FlagSet, group string) ([]string, bool) {
+	var result []string
+	for i, c := range group {
+		f := fs.Lookup(string(c))
+		if f == nil {
+			return nil, false
 		}
-		// Если одно из значений не является числом, то считаем, что оно больше
-		if aErr != nil && bErr == nil {
-			return false
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+			result = append(result, "-"+string(c))
+			continue
 		}
-		if aErr == nil && bErr != nil {
-			return true
+		result = append(result, "-"+string(c))
+		if rest := group[i+len(string(c)):]; rest != "" {
+			result = append(result, rest)
 		}
+		return result, true
 	}
+	return result, true
+}
 
-	if sf.month {
-		return monthLess(a, b)
-	}
-
-	if sf.human {
-		return humanNumericLess(a, b)
+// readInput читает записи из всех входных данных по очереди
+func readInput(readers []io.Reader, sf SortFlags) ([]string, error) {
+	var lines []string
+	for _, r := range readers {
+		scanner := newRecordScanner(r, sf)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
 	}
+	return lines, nil
+}
 
-	// Обычное строковое сравнение
-	result := a < b
-
-	// Обработка обратного порядка
-	if sf.reverse {
-		result = !result
-	}
+// monthLess сравнивает строки как месяцы; строки без названия месяца идут
+// первыми и равны между собой
+func monthLess(a, b string) bool {
+	return monthNumber(a) < monthNumber(b)
+}
 
-	return result
+// less сравнивает две строки в зависимости от флагов
+func less(a, b string, sf SortFlags) bool {
+	return compareLines(a, b, sf) < 0
 }
 
 // unique удаляет дублирующиеся строки
@@ -165,24 +197,31 @@ func unique(lines []string) []string {
 	return result
 }
 
-// sortLines сортирует строки в зависимости от флагов
-func sortLines(lines []string, sf SortFlags) []string {
-	if sf.check {
-		if checkSorted(lines, sf) {
-			fmt.Println("Строки уже отсортированы")
-		} else {
-			fmt.Println("Строки не отсортированы")
-			os.Exit(1)
+// uniqueKeys оставляет первую строку из каждой серии соседних строк с
+// равными ключами; строки должны быть отсортированы
+func uniqueKeys(lines []string, sf SortFlags) []string {
+	var result []string
+	for i, line := range lines {
+		if i == 0 || compareKeys(result[len(result)-1], line, sf) != 0 {
+			result = append(result, line)
 		}
-		return lines
 	}
+	return result
+}
 
-	sort.SliceStable(lines, func(i, j int) bool {
-		return less(lines[i], lines[j], sf)
+// sortLines сортирует строки в зависимости от флагов
+func sortLines(lines []string, sf SortFlags) []string {
+	stableSort(lines, sf.parallel, func(a, b string) bool {
+		return less(a, b, sf)
 	})
 
 	if sf.unique {
-		lines = unique(lines)
+		// При побайтовом сравнении строк целиком равенство ключей совпадает с равенством строк
+		if sf.bytewise() {
+			lines = unique(lines)
+		} else {
+			lines = uniqueKeys(lines, sf)
+		}
 	}
 
 	return lines
@@ -190,14 +229,14 @@ func sortLines(lines []string, sf SortFlags) []string {
 
 func main() {
 	sf := parseFlags()
-	lines, err := readInput()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Ошибка чтения входных данных: %v\n", err)
+	if err := run(sf, flag.Args(), os.Stdout); err != nil {
+		var disorder *disorderError
+		switch {
+		case !errors.As(err, &disorder):
+			fmt.Fprintf(os.Stderr, "Ошибка сортировки: %v\n", err)
+		case !sf.quietCheck:
+			fmt.Fprintf(os.Stderr, "sort: %v\n", err)
+		}
 		os.Exit(1)
 	}
-
-	sortedLines := sortLines(lines, sf)
-	for _, line := range sortedLines {
-		fmt.Println(line)
-	}
 }
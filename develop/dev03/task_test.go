@@ -14,7 +14,7 @@ func TestLess(t *testing.T) {
 	}{
 		{"Simple strings", "apple", "banana", SortFlags{}, true},
 		{"Numeric strings", "10", "2", SortFlags{numeric: true}, false},
-		{"Non-numeric strings", "apple", "2", SortFlags{numeric: true}, false},
+		{"Non-numeric strings", "apple", "2", SortFlags{numeric: true}, true},
 		{"Month sorting", "Jan", "Feb", SortFlags{month: true}, true},
 		{"Ignoring blanks", "   apple  ", "banana", SortFlags{ignoreBlanks: true}, true},
 		{"Reverse sorting", "banana", "apple", SortFlags{reverse: true}, true},
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// indexMagic — сигнатура файла индекса анаграмм и версия формата
+const (
+	indexMagic   = "ANGI"
+	indexVersion = 1
+)
+
+// maxIndexWordLen — максимальная длина слова индекса в байтах. Более
+// длинные слова не добавляются, поэтому любой записанный индекс читается.
+const maxIndexWordLen = 1 << 16
+
+// ErrInvalidIndex возвращается при чтении поврежденного индекса
+var ErrInvalidIndex = errors.New("некорректный индекс анаграмм")
+
+// anagramSet — множество слов из одних и тех же букв
+type anagramSet struct {
+	first string   // первое встретившееся в словаре слово
+	words []string // слова по возрастанию, без повторов
+}
+
+// AnagramIndex — индекс множеств анаграмм, который пополняется по мере
+// чтения словаря. Множество слова находится по его отсортированным буквам
+// за O(1). Индекс не безопасен для конкурентного использования.
+type AnagramIndex struct {
+	sets  map[string]*anagramSet
+	words int
+}
+
+// NewAnagramIndex создает пустой индекс
+func NewAnagramIndex() *AnagramIndex {
+	return &AnagramIndex{sets: make(map[string]*anagramSet)}
+}
+
+// Add добавляет слово в индекс; слово приводится к нижнему регистру,
+// пустые слова, слова длиннее maxIndexWordLen байт и повторы пропускаются
+func (ix *AnagramIndex) Add(word string) {
+	word = strings.ToLower(strings.TrimSpace(word))
+	if word == "" || len(word) > maxIndexWordLen {
+		return
+	}
+
+	key := sortString(word)
+	set, ok := ix.sets[key]
+	if !ok {
+		ix.sets[key] = &anagramSet{first: word, words: []string{word}}
+		ix.words++
+		return
+	}
+
+	i, found := slices.BinarySearch(set.words, word)
+	if !found {
+		set.words = slices.Insert(set.words, i, word)
+		ix.words++
+	}
+}
+
+// Ingest читает словарь из r по одному слову в строке и возвращает число
+// прочитанных строк. Словарь обрабатывается потоково и не хранится целиком.
+func (ix *AnagramIndex) Ingest(r io.Reader) (int, error) {
+	scanner := bufio.NewScanner(r)
+	n := 0
+	for scanner.Scan() {
+		ix.Add(scanner.Text())
+		n++
+	}
+	return n, scanner.Err()
+}
+
+// Len возвращает число различных слов в индексе
+func (ix *AnagramIndex) Len() int {
+	return ix.words
+}
+
+// Lookup возвращает отсортированное множество анаграмм слова, включая
+// само слово, если в словаре есть хотя бы одна его анаграмма, отличная от
+// него; иначе nil. Само слово может в словаре отсутствовать: для словаря
+// {"кот"} запрос "ток" дает ["кот", "ток"], а запрос "кот" — nil.
+func (ix *AnagramIndex) Lookup(word string) []string {
+	word = strings.ToLower(strings.TrimSpace(word))
+	set, ok := ix.sets[sortString(word)]
+	if !ok {
+		return nil
+	}
+
+	words := slices.Clone(set.words)
+	if i, found := slices.BinarySearch(words, word); !found {
+		words = slices.Insert(words, i, word)
+	}
+	if len(words) < 2 {
+		return nil
+	}
+	return words
+}
+
+// Sets возвращает множества анаграмм в формате findAnagramSets: ключ —
+// первое встретившееся слово множества, значение — слова по возрастанию.
+// Множества из одного слова не включаются.
+func (ix *AnagramIndex) Sets() map[string][]string {
+	result := make(map[string][]string)
+	for _, set := range ix.sets {
+		if len(set.words) > 1 {
+			result[set.first] = slices.Clone(set.words)
+		}
+	}
+	return result
+}
+
+// WriteTo записывает индекс в компактном двоичном формате: сигнатура и
+// версия, число множеств, затем для каждого множества число слов, номер
+// первого встретившегося слова и сами слова с длинами. Числа записываются
+// как uvarint, множества — в порядке ключей, поэтому результат
+// детерминирован. Ключи не сохраняются и вычисляются при чтении.
+func (ix *AnagramIndex) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	cw := &countingWriter{w: bw}
+
+	keys := make([]string, 0, len(ix.sets))
+	for key := range ix.sets {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	cw.writeString(indexMagic)
+	cw.writeUvarint(indexVersion)
+	cw.writeUvarint(uint64(len(keys)))
+	for _, key := range keys {
+		set := ix.sets[key]
+		first, _ := slices.BinarySearch(set.words, set.first)
+		cw.writeUvarint(uint64(len(set.words)))
+		cw.writeUvarint(uint64(first))
+		for _, word := range set.words {
+			cw.writeUvarint(uint64(len(word)))
+			cw.writeString(word)
+		}
+	}
+
+	if cw.err == nil {
+		cw.err = bw.Flush()
+	}
+	return cw.n, cw.err
+}
+
+// ReadAnagramIndex читает индекс, записанный WriteTo
+func ReadAnagramIndex(r io.Reader) (*AnagramIndex, error) {
+	br := bufio.NewReader(r)
+
+	magic := make([]byte, len(indexMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != indexMagic {
+		return nil, fmt.Errorf("%w: неверная сигнатура", ErrInvalidIndex)
+	}
+	version, err := readUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	if version != indexVersion {
+		return nil, fmt.Errorf("%w: неподдерживаемая версия %d", ErrInvalidIndex, version)
+	}
+
+	count, err := readUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+
+	ix := NewAnagramIndex()
+	for ; count > 0; count-- {
+		set, err := readAnagramSet(br)
+		if err != nil {
+			return nil, err
+		}
+		key := sortString(set.words[0])
+		if _, ok := ix.sets[key]; ok {
+			return nil, fmt.Errorf("%w: повторное множество %q", ErrInvalidIndex, set.words[0])
+		}
+		ix.sets[key] = set
+		ix.words += len(set.words)
+	}
+
+	if _, err := br.ReadByte(); err != io.EOF {
+		return nil, fmt.Errorf("%w: лишние данные в конце", ErrInvalidIndex)
+	}
+	return ix, nil
+}
+
+// readAnagramSet читает одно множество и проверяет, что слова в нем
+// упорядочены и состоят из одних и тех же букв
+func readAnagramSet(br *bufio.Reader) (*anagramSet, error) {
+	n, err := readUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	first, err := readUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 || first >= n {
+		return nil, fmt.Errorf("%w: некорректный размер множества", ErrInvalidIndex)
+	}
+
+	set := &anagramSet{}
+	var key string
+	for i := uint64(0); i < n; i++ {
+		size, err := readUvarint(br)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 || size > maxIndexWordLen {
+			return nil, fmt.Errorf("%w: некорректная длина слова %d", ErrInvalidIndex, size)
+		}
+		b := make([]byte, size)
+		if _, err := io.ReadFull(br, b); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidIndex, err)
+		}
+
+		word := string(b)
+		switch {
+		case i == 0:
+			key = sortString(word)
+		case word <= set.words[i-1] || sortString(word) != key:
+			return nil, fmt.Errorf("%w: слово %q не на своем месте", ErrInvalidIndex, word)
+		}
+		set.words = append(set.words, word)
+	}
+	set.first = set.words[first]
+	return set, nil
+}
+
+// readUvarint читает число в формате uvarint
+func readUvarint(br *bufio.Reader) (uint64, error) {
+	v, err := binary.ReadUvarint(br)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidIndex, err)
+	}
+	return v, nil
+}
+
+// SaveFile сохраняет индекс в файл. Данные пишутся во временный файл,
+// который затем переименовывается, поэтому при ошибке прежний файл цел.
+func (ix *AnagramIndex) SaveFile(name string) error {
+	f, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := ix.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
+
+// LoadAnagramIndexFile загружает индекс из файла, сохраненного SaveFile
+func LoadAnagramIndexFile(name string) (*AnagramIndex, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadAnagramIndex(f)
+}
+
+// countingWriter считает записанные байты и запоминает первую ошибку
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+// writeString записывает строку, если ранее не было ошибки
+func (cw *countingWriter) writeString(s string) {
+	if cw.err != nil {
+		return
+	}
+	n, err := io.WriteString(cw.w, s)
+	cw.n += int64(n)
+	cw.err = err
+}
+
+// writeUvarint записывает число в формате uvarint
+func (cw *countingWriter) writeUvarint(v uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	cw.writeString(string(buf[:binary.PutUvarint(buf[:], v)]))
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const dictionary = "Пятак\nпятка\n\nтяпка\nлисток\nслиток\nстолик\nкот\nток\nокт\nпятка\nслово\n"
+
+func TestAnagramIndexIngest(t *testing.T) {
+	ix := NewAnagramIndex()
+	n, err := ix.Ingest(strings.NewReader(dictionary))
+	if err != nil {
+		t.Fatalf("Ingest() вернула ошибку: %v", err)
+	}
+	if n != 12 {
+		t.Errorf("Ingest() = %d; expected 12", n)
+	}
+	if ix.Len() != 10 {
+		t.Errorf("Len() = %d; expected 10", ix.Len())
+	}
+
+	expected := map[string][]string{
+		"пятак":  {"пятак", "пятка", "тяпка"},
+		"листок": {"листок", "слиток", "столик"},
+		"кот":    {"кот", "окт", "ток"},
+	}
+	if got := ix.Sets(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Sets() = %v; expected %v", got, expected)
+	}
+
+	// Словарь можно дочитывать порциями
+	ix.Ingest(strings.NewReader("волос\nтокарь"))
+	expected["слово"] = []string{"волос", "слово"}
+	if got := ix.Sets(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Sets() после второй порции = %v; expected %v", got, expected)
+	}
+}
+
+func TestAnagramIndexLookup(t *testing.T) {
+	ix := NewAnagramIndex()
+	ix.Ingest(strings.NewReader(dictionary))
+
+	tests := []struct {
+		word     string
+		expected []string
+	}{
+		{"тяпка", []string{"пятак", "пятка", "тяпка"}},
+		{"КАПТЯ", []string{"каптя", "пятак", "пятка", "тяпка"}},
+		{"стилок", []string{"листок", "слиток", "стилок", "столик"}},
+		{" Тко ", []string{"кот", "окт", "тко", "ток"}},
+		{"слово", nil},
+		{"дом", nil},
+		{"", nil},
+	}
+
+	for _, test := range tests {
+		if got := ix.Lookup(test.word); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("Lookup(%q) = %v; expected %v", test.word, got, test.expected)
+		}
+	}
+
+	// Запрошенное слово дополняет множество, но одно слово множеством не является
+	single := NewAnagramIndex()
+	single.Add("кот")
+	if got := single.Lookup("ток"); !reflect.DeepEqual(got, []string{"кот", "ток"}) {
+		t.Errorf("Lookup(\"ток\") для словаря {кот} = %v; expected [кот ток]", got)
+	}
+	if got := single.Lookup("Кот"); got != nil {
+		t.Errorf("Lookup(\"Кот\") для словаря {кот} = %v; expected nil", got)
+	}
+
+	// Изменение результата не затрагивает индекс
+	ix.Lookup("кот")[0] = "изменено"
+	if got := ix.Lookup("кот"); got[0] != "кот" {
+		t.Errorf("Lookup() возвращает внутренний срез индекса: %v", got)
+	}
+}
+
+func TestAnagramIndexPersistence(t *testing.T) {
+	ix := NewAnagramIndex()
+	ix.Ingest(strings.NewReader(dictionary))
+
+	var buf bytes.Buffer
+	n, err := ix.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("WriteTo() вернула ошибку: %v", err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("WriteTo() = %d; expected %d", n, buf.Len())
+	}
+
+	loaded, err := ReadAnagramIndex(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadAnagramIndex() вернула ошибку: %v", err)
+	}
+	if !reflect.DeepEqual(loaded.Sets(), ix.Sets()) || loaded.Len() != ix.Len() {
+		t.Errorf("ReadAnagramIndex() = %v; expected %v", loaded.Sets(), ix.Sets())
+	}
+
+	// Одиночные слова сохраняются и могут образовать множество позже
+	loaded.Add("волос")
+	if got := loaded.Lookup("слово"); !reflect.DeepEqual(got, []string{"волос", "слово"}) {
+		t.Errorf("Lookup() после загрузки = %v", got)
+	}
+
+	// Запись детерминирована
+	var again bytes.Buffer
+	ix.WriteTo(&again)
+	if !bytes.Equal(buf.Bytes(), again.Bytes()) {
+		t.Errorf("Повторная запись индекса дает другие данные")
+	}
+
+	name := filepath.Join(t.TempDir(), "index.bin")
+	if err := ix.SaveFile(name); err != nil {
+		t.Fatalf("SaveFile() вернула ошибку: %v", err)
+	}
+	fromFile, err := LoadAnagramIndexFile(name)
+	if err != nil {
+		t.Fatalf("LoadAnagramIndexFile() вернула ошибку: %v", err)
+	}
+	if !reflect.DeepEqual(fromFile.Sets(), ix.Sets()) {
+		t.Errorf("LoadAnagramIndexFile() = %v; expected %v", fromFile.Sets(), ix.Sets())
+	}
+
+	// Слова длиннее maxIndexWordLen не добавляются, и индекс читается
+	long := NewAnagramIndex()
+	long.Add(strings.Repeat("я", maxIndexWordLen))
+	long.Add(strings.Repeat("a", maxIndexWordLen))
+	if long.Len() != 1 {
+		t.Errorf("Len() после добавления длинных слов = %d; expected 1", long.Len())
+	}
+	buf.Reset()
+	long.WriteTo(&buf)
+	loaded, err = ReadAnagramIndex(&buf)
+	if err != nil {
+		t.Fatalf("ReadAnagramIndex() для слова длины maxIndexWordLen вернула ошибку: %v", err)
+	}
+	if loaded.Len() != 1 {
+		t.Errorf("ReadAnagramIndex().Len() = %d; expected 1", loaded.Len())
+	}
+}
+
+func TestReadAnagramIndexInvalid(t *testing.T) {
+	ix := NewAnagramIndex()
+	ix.Ingest(strings.NewReader("кот\nток\n"))
+	var buf bytes.Buffer
+	ix.WriteTo(&buf)
+	valid := buf.Bytes()
+
+	tests := map[string][]byte{
+		"пустые данные":      nil,
+		"неверная сигнатура": append([]byte("XXXX"), valid[4:]...),
+		"неверная версия":    append([]byte("ANGI\x02"), valid[5:]...),
+		"обрезанные данные":  valid[:len(valid)-1],
+		"лишние данные":      append(append([]byte(nil), valid...), 0),
+		"нарушен порядок":    []byte("ANGI\x01\x01\x02\x00\x06ток\x06кот"),
+		"разные буквы":       []byte("ANGI\x01\x01\x02\x00\x06кот\x06кит"),
+		"номер первого":      []byte("ANGI\x01\x01\x01\x01\x06кот"),
+		"повтор множества":   []byte("ANGI\x01\x02\x01\x00\x06кот\x01\x00\x06ток"),
+	}
+
+	for name, data := range tests {
+		if _, err := ReadAnagramIndex(bytes.NewReader(data)); !errors.Is(err, ErrInvalidIndex) {
+			t.Errorf("%s: ReadAnagramIndex() = %v; expected ErrInvalidIndex", name, err)
+		}
+	}
+}
+
+func BenchmarkAnagramIndexIngest(b *testing.B) {
+	var dict strings.Builder
+	for i := 0; i < 100000; i++ {
+		fmt.Fprintf(&dict, "слово%d\n", i%50000)
+	}
+	data := dict.String()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		NewAnagramIndex().Ingest(strings.NewReader(data))
+	}
+}
@@ -21,53 +21,24 @@ package main
 
 import (
 	"fmt"
-	"sort"
-	"strings"
+	"slices"
 )
 
+// findAnagramSets возвращает множества анаграмм словаря words; ключ —
+// первое встретившееся слово множества
 func findAnagramSets(words []string) map[string][]string {
-	anagramSets := make(map[string][]string)
-
-	// Создаем мапу для хранения сортированных слов и их оригинальных версий
-	sortedWords := make(map[string]string)
-
-	// Проходим по всем словам во входном массиве
+	ix := NewAnagramIndex()
 	for _, word := range words {
-		// Приводим слово к нижнему регистру
-		word = strings.ToLower(word)
-
-		// Создаем сортированную версию слова для использования в качестве ключа мапы
-		sortedWord := sortString(word)
-
-		// Если такой ключ уже существует в мапе, добавляем оригинальное слово в соответствующий массив
-		if _, ok := sortedWords[sortedWord]; ok {
-			anagramSets[sortedWords[sortedWord]] = append(anagramSets[sortedWords[sortedWord]], word)
-		} else {
-			// Если ключа еще нет, создаем новую запись в мапе и в мапе для анаграмм
-			sortedWords[sortedWord] = word
-			anagramSets[word] = []string{word}
-		}
+		ix.Add(word)
 	}
-
-	// Удаляем все множества из одного элемента из результата
-	for key, value := range anagramSets {
-		if len(value) <= 1 {
-			delete(anagramSets, key)
-		} else {
-			// Сортируем массив анаграмм по возрастанию перед добавлением в результат
-			sort.Strings(value)
-			anagramSets[key] = value
-		}
-	}
-
-	return anagramSets
+	return ix.Sets()
 }
 
 // Вспомогательная функция для сортировки символов в строке
 func sortString(s string) string {
-	chars := strings.Split(s, "")
-	sort.Strings(chars)
-	return strings.Join(chars, "")
+	runes := []rune(s)
+	slices.Sort(runes)
+	return string(runes)
 }
 
 func main() {